@@ -2,18 +2,20 @@ package cmd
 
 import (
 	"fmt"
-	"net"
-	"os"
-
-	"golang.org/x/net/idna"
+	"net/netip"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tifye/whosts/pkg"
 )
 
 type addOptions struct {
-	ip   net.IP
-	host string
+	ip      string
+	host    string
+	ttl     time.Duration
+	expires string
+	tags    map[string]string
 }
 
 func newAddCommand() *cobra.Command {
@@ -23,18 +25,20 @@ func newAddCommand() *cobra.Command {
 		Short: "Add an entry",
 		Args: func(cmd *cobra.Command, args []string) error {
 			if err := cobra.ExactArgs(2)(cmd, args); err != nil {
-				return fmt.Errorf("accepts 2 positional args: <ip> <host>")
+				return fmt.Errorf("accepts 2 positional args: <ip|@variable> <host>")
 			}
 
-			ipStr, host := args[0], args[1]
-			ip := net.ParseIP(ipStr)
-			if ip == nil {
-				return fmt.Errorf("provided IP is not a valid textual represetation of an IP address")
+			// Variables are resolved once the hosts file is read.
+			ip := args[0]
+			if !strings.HasPrefix(ip, "@") {
+				if _, err := parseIP(ip); err != nil {
+					return err
+				}
 			}
 
-			_, err := idna.Lookup.ToASCII(host)
-			if err != nil {
-				return fmt.Errorf("invalid host name format, expected format as defined in RFC 5891")
+			host := args[1]
+			if err := pkg.ValidateHost(host); err != nil {
+				return err
 			}
 
 			opts.ip = ip
@@ -42,35 +46,82 @@ func newAddCommand() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			hostsFile, err := cmd.Flags().GetString("hosts")
-			if err != nil {
-				return err
-			}
+			return forEachTarget(cmd, func(hostsFile string) error {
+				return addEntry(cmd, hostsFile, opts)
+			})
+		},
+	}
 
-			file, err := os.OpenFile(hostsFile, os.O_RDWR, 0755)
-			if err != nil {
-				return fmt.Errorf("open file: %s", err)
-			}
-			defer file.Close()
+	cmd.Flags().DurationVar(&opts.ttl, "ttl", 0, "Expire the entry after this duration, e.g. 2h")
+	cmd.Flags().StringVar(&opts.expires, "expires", "", "Expire the entry at this time, e.g. 2006-01-02T15:04")
+	cmd.MarkFlagsMutuallyExclusive("ttl", "expires")
+	cmd.Flags().StringToStringVar(&opts.tags, "tag", nil, "Tag the entry with key=value, e.g. owner=platform")
 
-			hosts, err := pkg.ParseEntries(file)
-			if err != nil {
-				return err
-			}
+	return cmd
+}
 
-			hosts.AddEntry(pkg.Entry{
-				IP:   opts.ip,
-				Host: opts.host,
-			})
+func addEntry(cmd *cobra.Command, hostsFile string, opts addOptions) error {
+	hosts, err := pkg.ReadFile(hostsFile)
+	if err != nil {
+		return err
+	}
 
-			if _, err = hosts.WriteTo(file); err != nil {
-				return err
-			}
+	ip, name, err := resolveIP(hosts, opts.ip)
+	if err != nil {
+		return err
+	}
 
-			fmt.Println(hosts.String())
+	entry := pkg.Entry{
+		IP:   ip,
+		Host: opts.host,
+		Var:  name,
+	}
+	if err := entry.SetTags(opts.tags); err != nil {
+		return err
+	}
+	if opts.ttl > 0 {
+		entry.SetExpires(time.Now().Add(opts.ttl))
+	}
+	if opts.expires != "" {
+		expires, err := parseTime(opts.expires)
+		if err != nil {
+			return err
+		}
+		entry.SetExpires(expires)
+	}
 
-			return nil
-		},
+	updated := hosts.Clone()
+	if err := updated.AddEntry(entry); err != nil {
+		return err
 	}
-	return cmd
+
+	if err := writeHosts(cmd.Context(), hostsFile, hosts, updated); err != nil {
+		return err
+	}
+
+	fmt.Println(updated.String())
+
+	return nil
+}
+
+func parseIP(s string) (netip.Addr, error) {
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("provided IP is not a valid textual represetation of an IP address")
+	}
+	return ip, nil
+}
+
+// parseTime parses an RFC 3339 time, or a date with an optional time of
+// day in the local time zone.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected format such as 2006-01-02T15:04", s)
 }
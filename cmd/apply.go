@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultDesiredFile = "hosts.desired.yaml"
+
+// desiredState is the content of a desired state file, declaring the
+// entries of the section it manages.
+type desiredState struct {
+	Section string         `yaml:"section"`
+	Entries []desiredEntry `yaml:"entries"`
+}
+
+type desiredEntry struct {
+	IP       string            `yaml:"ip"`
+	Host     string            `yaml:"host"`
+	Aliases  []string          `yaml:"aliases"`
+	Comment  string            `yaml:"comment"`
+	Tags     map[string]string `yaml:"tags"`
+	Disabled bool              `yaml:"disabled"`
+}
+
+func readDesiredState(path string) (desiredState, []pkg.Entry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return desiredState{}, nil, fmt.Errorf("read desired state: %s", err)
+	}
+
+	var state desiredState
+	if err := yaml.Unmarshal(b, &state); err != nil {
+		return desiredState{}, nil, fmt.Errorf("parse %s: %s", path, err)
+	}
+	if state.Section == "" {
+		return desiredState{}, nil, fmt.Errorf("%s: section is required", path)
+	}
+
+	entries := make([]pkg.Entry, 0, len(state.Entries))
+	for i, de := range state.Entries {
+		ip, err := parseIP(de.IP)
+		if err != nil {
+			return desiredState{}, nil, fmt.Errorf("%s: entry %d: %s", path, i, err)
+		}
+		entry := pkg.Entry{
+			IP:       ip,
+			Host:     de.Host,
+			Aliases:  de.Aliases,
+			Comment:  de.Comment,
+			Disabled: de.Disabled,
+		}
+		for _, name := range entry.Names() {
+			if err := pkg.ValidateHost(name); err != nil {
+				return desiredState{}, nil, fmt.Errorf("%s: entry %d: %s", path, i, err)
+			}
+		}
+		if entry.Comment != "" && entry.Comment[0] != '#' {
+			entry.Comment = "# " + entry.Comment
+		}
+		if len(de.Tags) > 0 {
+			if err := entry.SetTags(de.Tags); err != nil {
+				return desiredState{}, nil, fmt.Errorf("%s: entry %d: %s", path, i, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return state, entries, nil
+}
+
+func newPlanCommand() *cobra.Command {
+	var (
+		file  string
+		force bool
+	)
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show the changes needed to match the desired state file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			state, entries, err := readDesiredState(file)
+			if err != nil {
+				return err
+			}
+
+			planned := hosts.Clone()
+			changes, skipped := replaceSection(&planned, state.Section, entries, force)
+			printPlan(state.Section, changes)
+			printSkipped(skipped)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", defaultDesiredFile, "Desired state file")
+	cmd.MarkFlagFilename("file", "yaml", "yml")
+	cmd.Flags().BoolVar(&force, "force", false, "Also change protected entries of the section")
+
+	return cmd
+}
+
+func newApplyCommand() *cobra.Command {
+	var (
+		file  string
+		force bool
+	)
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Update the section managed by the desired state file to match it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			state, entries, err := readDesiredState(file)
+			if err != nil {
+				return err
+			}
+
+			updated := hosts.Clone()
+			changes, skipped := replaceSection(&updated, state.Section, entries, force)
+			printPlan(state.Section, changes)
+			printSkipped(skipped)
+			if len(changes) == 0 {
+				return nil
+			}
+
+			if err := writeHosts(cmd.Context(), hostsFile, hosts, updated); err != nil {
+				return err
+			}
+
+			fmt.Printf("\nApplied %d change(s) to %s\n", len(changes), hostsFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", defaultDesiredFile, "Desired state file")
+	cmd.MarkFlagFilename("file", "yaml", "yml")
+	cmd.Flags().BoolVar(&force, "force", false, "Also change protected entries of the section")
+
+	return cmd
+}
+
+// replaceSection replaces the entries of the named section of hosts
+// with entries. Unless force is set, protected entries of the section
+// are kept as they are and returned as skipped.
+func replaceSection(hosts *pkg.Hosts, section string, entries []pkg.Entry, force bool) ([]pkg.Change, []pkg.Entry) {
+	original := hosts.Clone()
+	changes := hosts.ReplaceSection(section, entries)
+	if force {
+		return changes, nil
+	}
+
+	desired := slices.Clone(entries)
+	var skipped []pkg.Entry
+	for _, c := range changes {
+		if c.Kind == pkg.Added || !c.Old.Protected() {
+			continue
+		}
+		skipped = append(skipped, c.Old)
+		if i := slices.IndexFunc(desired, func(e pkg.Entry) bool { return c.Kind == pkg.Changed && e.String() == c.New.String() }); i >= 0 {
+			desired[i] = c.Old
+		} else {
+			desired = append(desired, c.Old)
+		}
+	}
+	if len(skipped) == 0 {
+		return changes, nil
+	}
+
+	*hosts = original
+	return hosts.ReplaceSection(section, desired), skipped
+}
+
+func printPlan(section string, changes []pkg.Change) {
+	if len(changes) == 0 {
+		fmt.Printf("Section %q is up to date.\n", section)
+		return
+	}
+
+	var create, update, del int
+	for _, c := range changes {
+		switch c.Kind {
+		case pkg.Added:
+			create++
+			fmt.Printf("  create  %s\n", c.New)
+		case pkg.Changed:
+			update++
+			fmt.Printf("  update  %s\n       -> %s\n", c.Old, c.New)
+		case pkg.Removed:
+			del++
+			fmt.Printf("  delete  %s\n", c.Old)
+		}
+	}
+	fmt.Printf("\nPlan for section %q: %d to create, %d to update, %d to delete.\n", section, create, update, del)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+func newBatchCommand() *cobra.Command {
+	var (
+		dryRun bool
+		force  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "batch <file|->",
+		Short: "Apply a file of operations to the hosts file at once, writing all or none of them",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input, err := openInput(args[0])
+			if err != nil {
+				return fmt.Errorf("open batch file: %s", err)
+			}
+			ops, err := pkg.ParseBatch(input)
+			input.Close()
+			if err != nil {
+				return err
+			}
+
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			updated, skipped, err := pkg.ApplyBatch(hosts, ops, force)
+			if err != nil {
+				return fmt.Errorf("%s, nothing was written", err)
+			}
+
+			changes := pkg.Diff(hosts, updated)
+			for _, c := range changes {
+				fmt.Println(c)
+			}
+			printSkipped(skipped)
+			if len(changes) == 0 {
+				fmt.Println("No changes made")
+				return nil
+			}
+			if dryRun {
+				return nil
+			}
+			if err := writeHosts(cmd.Context(), hostsFile, hosts, updated); err != nil {
+				return err
+			}
+			fmt.Printf("Applied %d operation(s), %d entries changed\n", len(ops), len(changes))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry", false, "Dry run command and print out the changes without writing them")
+	cmd.Flags().BoolVar(&force, "force", false, "Also change protected entries")
+
+	return cmd
+}
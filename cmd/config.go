@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/tifye/whosts/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// config is the content of the whosts config file.
+type config struct {
+	Hooks hooksConfig `yaml:"hooks"`
+	// Flush flushes the DNS resolver caches after every write, unless
+	// overridden with --flush.
+	Flush bool `yaml:"flush"`
+	// Targets are named groups of hosts files, targeted with --hosts @name.
+	Targets map[string][]string `yaml:"targets"`
+	Guard   guardConfig         `yaml:"guard"`
+}
+
+// defaultGuardPercent is the share of entries a write may remove or
+// rewrite when the config does not set one.
+const defaultGuardPercent = 20
+
+// guardConfig limits how many entries a single write may remove or
+// rewrite without --yes. A percent of 0 turns off the share limit.
+type guardConfig struct {
+	Percent *float64 `yaml:"percent"`
+	Count   int      `yaml:"count"`
+}
+
+func (g guardConfig) limit() pkg.ChangeLimit {
+	limit := pkg.ChangeLimit{Percent: defaultGuardPercent, Count: g.Count}
+	if g.Percent != nil {
+		limit.Percent = *g.Percent
+	}
+	return limit
+}
+
+// hooksConfig lists shell commands run before and after every write to
+// a hosts file.
+type hooksConfig struct {
+	Pre  []string `yaml:"pre"`
+	Post []string `yaml:"post"`
+}
+
+// configPath returns $WHOSTS_CONFIG, or config.yaml in the data dir.
+func configPath() (string, error) {
+	if path := os.Getenv("WHOSTS_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// loadConfig reads the config file. A missing file is an empty config.
+func loadConfig() (config, error) {
+	path, err := configPath()
+	if err != nil {
+		return config{}, err
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config{}, nil
+	}
+	if err != nil {
+		return config{}, fmt.Errorf("read config: %s", err)
+	}
+
+	var cfg config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return config{}, fmt.Errorf("parse %s: %s", path, err)
+	}
+	return cfg, nil
+}
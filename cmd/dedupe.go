@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+type dedupeOptions struct {
+	by      string
+	keep    string
+	section string
+	force   bool
+	dryRun  bool
+}
+
+func newDedupeCommand() *cobra.Command {
+	opts := &dedupeOptions{}
+	cmd := &cobra.Command{
+		Use:   "dedupe",
+		Short: "Remove entries that point the same names at the same address",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			by, err := pkg.ParseDedupeKey(opts.by)
+			if err != nil {
+				return err
+			}
+			keep, err := pkg.ParseKeepStrategy(opts.keep)
+			if err != nil {
+				return err
+			}
+
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			updated := hosts.Clone()
+			changes, skipped := updated.Dedupe(by, keep, opts.section, opts.force)
+			if len(changes) == 0 {
+				fmt.Println("No duplicates")
+				printSkipped(skipped)
+				return nil
+			}
+			for _, c := range changes {
+				fmt.Println(c)
+			}
+			printSkipped(skipped)
+			if opts.dryRun {
+				return nil
+			}
+			return writeHosts(cmd.Context(), hostsFile, hosts, updated)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.by, "by", string(pkg.ByHostIP), "What duplicates have in common: host, host+ip or host+family")
+	cmd.Flags().StringVar(&opts.keep, "keep", string(pkg.KeepFirst), "Which duplicate to keep: first, last or merge-comments to keep the first with the comments of all")
+	cmd.Flags().StringVar(&opts.section, "section", "", "Dedupe the entries of this section instead of the ones outside of any section")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Also remove protected entries")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry", false, "Dry run command and print out which entries would have been affected")
+
+	return cmd
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+func newDNSCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dns",
+		Short: "Answer DNS queries from the hosts file",
+	}
+	cmd.AddCommand(newDNSServeCommand())
+	return cmd
+}
+
+type dnsServeOptions struct {
+	listen   string
+	upstream string
+	ttl      uint32
+	poll     time.Duration
+}
+
+func newDNSServeCommand() *cobra.Command {
+	opts := &dnsServeOptions{}
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve A, AAAA and PTR records for the entries in the hosts file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			handler := &dnsHandler{
+				hosts:    hosts,
+				upstream: opts.upstream,
+				ttl:      opts.ttl,
+			}
+
+			errs := make(chan error, 3)
+			servers := []*dns.Server{
+				{Addr: opts.listen, Net: "udp", Handler: handler},
+				{Addr: opts.listen, Net: "tcp", Handler: handler},
+			}
+			for _, srv := range servers {
+				go func() {
+					errs <- srv.ListenAndServe()
+				}()
+			}
+			defer func() {
+				for _, srv := range servers {
+					_ = srv.Shutdown()
+				}
+			}()
+
+			go func() {
+				errs <- watchFile(cmd.Context(), hostsFile, opts.poll, func() {
+					hosts, err := pkg.ReadFile(hostsFile)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "reload: %s\n", err)
+						return
+					}
+					handler.reload(hosts)
+					fmt.Fprintf(os.Stderr, "Reloaded %d entries from %s\n", len(hosts.Entries()), hostsFile)
+				})
+			}()
+
+			fmt.Fprintf(os.Stderr, "Listening on %s\n", opts.listen)
+			select {
+			case <-cmd.Context().Done():
+				return nil
+			case err := <-errs:
+				if err != nil {
+					return fmt.Errorf("dns serve: %s", err)
+				}
+				return nil
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.listen, "listen", "127.0.0.1:5353", "Address to listen on for UDP and TCP queries")
+	cmd.Flags().StringVar(&opts.upstream, "upstream", "", "DNS server to forward other queries to, e.g. 1.1.1.1:53. Without one they are answered with NXDOMAIN")
+	cmd.Flags().Uint32Var(&opts.ttl, "ttl", 60, "TTL in seconds of answered records")
+	cmd.Flags().DurationVar(&opts.poll, "poll", 0, "Poll the hosts file for changes at this interval instead of using file system notifications")
+
+	return cmd
+}
+
+type dnsHandler struct {
+	mu    sync.RWMutex
+	hosts pkg.Hosts
+
+	upstream string
+	ttl      uint32
+}
+
+func (h *dnsHandler) reload(hosts pkg.Hosts) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hosts = hosts
+}
+
+func (h *dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	if len(r.Question) != 1 {
+		msg := new(dns.Msg)
+		msg.SetRcode(r, dns.RcodeFormatError)
+		_ = w.WriteMsg(msg)
+		return
+	}
+
+	msg, ok := h.answer(r)
+	if !ok && h.upstream != "" {
+		resp, err := h.forward(w, r)
+		if err == nil {
+			_ = w.WriteMsg(resp)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "forward %s: %s\n", r.Question[0].Name, err)
+		msg = new(dns.Msg)
+		msg.SetRcode(r, dns.RcodeServerFailure)
+	}
+	_ = w.WriteMsg(msg)
+}
+
+// answer answers r from the hosts file. It reports false when none of
+// the entries name the queried host, leaving the reply as NXDOMAIN.
+func (h *dnsHandler) answer(r *dns.Msg) (*dns.Msg, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	q := r.Question[0]
+	msg := new(dns.Msg)
+	msg.SetReply(r)
+	msg.Authoritative = true
+	header := dns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: dns.ClassINET, Ttl: h.ttl}
+
+	if q.Qtype == dns.TypePTR {
+		ip := pkg.ReverseAddr(q.Name)
+		if !ip.IsValid() {
+			msg.Rcode = dns.RcodeNameError
+			return msg, false
+		}
+		names := h.hosts.LookupAddr(ip)
+		if len(names) == 0 {
+			msg.Rcode = dns.RcodeNameError
+			return msg, false
+		}
+		msg.Answer = append(msg.Answer, &dns.PTR{Hdr: header, Ptr: dns.Fqdn(names[0])})
+		return msg, true
+	}
+
+	ips := h.hosts.LookupHost(q.Name)
+	if len(ips) == 0 {
+		msg.Rcode = dns.RcodeNameError
+		return msg, false
+	}
+
+	// Like the system resolver, the first entry for a name wins. Names
+	// without a record of the queried type get an empty answer.
+	for _, ip := range ips {
+		ip = ip.Unmap()
+		if q.Qtype == dns.TypeA && ip.Is4() {
+			msg.Answer = append(msg.Answer, &dns.A{Hdr: header, A: ip.AsSlice()})
+			break
+		}
+		if q.Qtype == dns.TypeAAAA && ip.Is6() {
+			msg.Answer = append(msg.Answer, &dns.AAAA{Hdr: header, AAAA: ip.WithZone("").AsSlice()})
+			break
+		}
+	}
+	return msg, true
+}
+
+func (h *dnsHandler) forward(w dns.ResponseWriter, r *dns.Msg) (*dns.Msg, error) {
+	client := &dns.Client{Net: "udp", Timeout: 5 * time.Second}
+	if _, ok := w.RemoteAddr().(*net.TCPAddr); ok {
+		client.Net = "tcp"
+	}
+	resp, _, err := client.Exchange(r, h.upstream)
+	return resp, err
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+const defaultDockerSocket = "/var/run/docker.sock"
+
+type syncDockerOptions struct {
+	section  string
+	socket   string
+	fromJSON string
+	dryRun   bool
+}
+
+func newSyncDockerCommand() *cobra.Command {
+	opts := &syncDockerOptions{}
+	cmd := &cobra.Command{
+		Use:   "docker",
+		Short: "Reconcile a section with the names and addresses of running Docker containers",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var containers []pkg.DockerContainer
+			var err error
+			if opts.fromJSON != "" {
+				containers, err = readDockerInspect(opts.fromJSON)
+			} else {
+				containers, err = fetchDockerContainers(cmd.Context(), opts.socket)
+			}
+			if err != nil {
+				return err
+			}
+
+			return syncSection(cmd, opts.section, dockerEntries(containers), opts.dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.section, "section", "docker", "Section to reconcile")
+	cmd.Flags().StringVar(&opts.socket, "socket", dockerSocket(), "Docker Engine API unix socket")
+	cmd.Flags().StringVar(&opts.fromJSON, "from-json", "", "Read the output of docker inspect from this file, or - for stdin, instead of using the Docker Engine API")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry", false, "Dry run command and print out the changes that would have been made")
+	cmd.MarkFlagsMutuallyExclusive("socket", "from-json")
+
+	return cmd
+}
+
+// dockerSocket returns the socket of a unix:// $DOCKER_HOST, or the
+// default socket.
+func dockerSocket() string {
+	if path, ok := strings.CutPrefix(os.Getenv("DOCKER_HOST"), "unix://"); ok {
+		return path
+	}
+	return defaultDockerSocket
+}
+
+func readDockerInspect(name string) ([]pkg.DockerContainer, error) {
+	f, err := openInput(name)
+	if err != nil {
+		return nil, fmt.Errorf("read docker inspect output: %s", err)
+	}
+	defer f.Close()
+
+	var containers []pkg.DockerContainer
+	if err := json.NewDecoder(f).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("parse docker inspect output: %s", err)
+	}
+	return containers, nil
+}
+
+func fetchDockerContainers(ctx context.Context, socket string) ([]pkg.DockerContainer, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	var list []struct {
+		ID string `json:"Id"`
+	}
+	if err := dockerGet(ctx, client, "/containers/json", &list); err != nil {
+		return nil, err
+	}
+
+	containers := make([]pkg.DockerContainer, 0, len(list))
+	for _, c := range list {
+		var container pkg.DockerContainer
+		if err := dockerGet(ctx, client, "/containers/"+c.ID+"/json", &container); err != nil {
+			return nil, err
+		}
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
+
+func dockerGet(ctx context.Context, client *http.Client, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker"+path, nil)
+	if err != nil {
+		return fmt.Errorf("docker: %s", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("docker: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker: GET %s: %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("docker: decode %s: %s", path, err)
+	}
+	return nil
+}
+
+// dockerEntries returns an entry for every address of every running
+// container, warning about the names that are skipped.
+func dockerEntries(containers []pkg.DockerContainer) []pkg.Entry {
+	entries, errs := pkg.DockerEntries(containers)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "skipping %s\n", err)
+	}
+	return entries
+}
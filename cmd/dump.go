@@ -13,7 +13,7 @@ func newDumpCommand() *cobra.Command {
 		Use:   "dump",
 		Short: "Dumps file contents to stdout",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			hostsFile, err := cmd.Flags().GetString("hosts")
+			hostsFile, err := hostsPath(cmd)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"net/netip"
+
+	"github.com/tifye/whosts/pkg"
+)
+
+// entryFilter holds the entry filters shared by commands and the API.
+type entryFilter struct {
+	ip        netip.Addr
+	host      string
+	comment   string
+	section   string
+	tags      map[string]string
+	noComment bool
+}
+
+func (f entryFilter) filters() []pkg.FilterOption {
+	filters := make([]pkg.FilterOption, 0)
+	if f.ip.IsValid() {
+		filters = append(filters, pkg.WithIPs(f.ip))
+	}
+	if f.host != "" {
+		filters = append(filters, pkg.WithHosts(f.host))
+	}
+	if f.comment != "" {
+		filters = append(filters, pkg.WithComments(f.comment))
+	}
+	if f.section != "" {
+		filters = append(filters, pkg.WithSections(f.section))
+	}
+	if len(f.tags) > 0 {
+		filters = append(filters, pkg.WithTags(f.tags))
+	}
+	if f.noComment {
+		filters = append(filters, pkg.WithNoComment())
+	}
+	return filters
+}
+
+// addrValue is a flag value holding an IP address, which unlike the IP
+// flags of pflag may be a zoned IPv6 address such as fe80::1%eth0.
+type addrValue struct {
+	addr *netip.Addr
+}
+
+func (v addrValue) String() string {
+	if v.addr == nil || !v.addr.IsValid() {
+		return ""
+	}
+	return v.addr.String()
+}
+
+func (v addrValue) Set(s string) error {
+	ip, err := parseIP(s)
+	if err != nil {
+		return err
+	}
+	*v.addr = ip
+	return nil
+}
+
+func (v addrValue) Type() string {
+	return "ip"
+}
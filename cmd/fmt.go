@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+type fmtOptions struct {
+	eol      string
+	encoding string
+}
+
+func newFmtCommand() *cobra.Command {
+	opts := &fmtOptions{}
+	encodings := make([]string, 0, len(pkg.Encodings))
+	for _, enc := range pkg.Encodings {
+		encodings = append(encodings, string(enc))
+	}
+
+	cmd := &cobra.Command{
+		Use:   "fmt",
+		Short: "Show or convert the encoding and line endings of the hosts file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			updated := hosts.Clone()
+			if opts.eol != "" {
+				eol, err := pkg.ParseEOL(opts.eol)
+				if err != nil {
+					return err
+				}
+				updated.SetEOL(eol)
+			}
+			if opts.encoding != "" {
+				enc, err := pkg.ParseEncoding(opts.encoding)
+				if err != nil {
+					return err
+				}
+				updated.SetEncoding(enc)
+			}
+
+			if updated.Encoding() == hosts.Encoding() && updated.EOL() == hosts.EOL() {
+				fmt.Printf("%s is %s with %s line endings\n", hostsFile, hosts.Encoding(), strings.ToUpper(string(hosts.EOL())))
+				return nil
+			}
+
+			if err := writeHosts(cmd.Context(), hostsFile, hosts, updated); err != nil {
+				return err
+			}
+
+			fmt.Printf("Converted %s to %s with %s line endings\n", hostsFile, updated.Encoding(), strings.ToUpper(string(updated.EOL())))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.eol, "eol", "", "Convert line endings to lf or crlf")
+	cmd.Flags().StringVar(&opts.encoding, "encoding", "", "Convert to encoding "+strings.Join(encodings, ", "))
+
+	return cmd
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+type gcOptions struct {
+	disable bool
+	dryRun  bool
+}
+
+func newGCCommand() *cobra.Command {
+	opts := &gcOptions{}
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove or disable expired entries",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			updated := hosts.Clone()
+			expired := collectExpired(&updated, time.Now(), opts.disable)
+			if len(expired) == 0 {
+				fmt.Println("No expired entries")
+				return nil
+			}
+
+			if !opts.dryRun {
+				if err := writeHosts(cmd.Context(), hostsFile, hosts, updated); err != nil {
+					return err
+				}
+			}
+
+			if opts.disable {
+				fmt.Printf("Disabled:\n%s", entriesText(expired))
+			} else {
+				fmt.Printf("Removed:\n%s", entriesText(expired))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.disable, "disable", false, "Disable expired entries instead of removing them")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry", false, "Dry run command and print out which entries would have been affected")
+
+	return cmd
+}
+
+func collectExpired(hosts *pkg.Hosts, now time.Time, disable bool) []pkg.Entry {
+	if disable {
+		return hosts.DisableExpired(now)
+	}
+	return hosts.RemoveExpired(now)
+}
+
+// lifetime describes how long until expires, or how long ago it was.
+func lifetime(expires, now time.Time) string {
+	d := expires.Sub(now).Round(time.Second)
+	if d > 0 {
+		return fmt.Sprintf("expires in %s", d)
+	}
+	return fmt.Sprintf("expired %s ago", -d)
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+func newHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Keep a git history of the hosts file and restore past versions",
+	}
+	cmd.AddCommand(
+		newHistoryInitCommand(),
+		newHistoryLogCommand(),
+		newHistoryShowCommand(),
+		newHistoryCheckoutCommand(),
+	)
+	return cmd
+}
+
+func newHistoryInitCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "init",
+		Short: "Start keeping a history, committing the hosts file after every write",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, err := hostsPath(cmd)
+			if err != nil {
+				return err
+			}
+			dir, err := historyDir()
+			if err != nil {
+				return err
+			}
+			history, err := pkg.InitHistory(cmd.Context(), dir)
+			if err != nil {
+				return err
+			}
+
+			content, err := os.ReadFile(hostsFile)
+			if err != nil {
+				return fmt.Errorf("read hosts file: %s", err)
+			}
+			if _, err := history.Commit(cmd.Context(), hostsFile, content, currentUser(), "Initial snapshot"); err != nil {
+				return err
+			}
+
+			fmt.Printf("Keeping the history of %s in %s\n", hostsFile, dir)
+			return nil
+		},
+	}
+}
+
+func newHistoryLogCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "log",
+		Short: "List the versions of the hosts file, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, err := hostsPath(cmd)
+			if err != nil {
+				return err
+			}
+			history, err := openHistory()
+			if err != nil {
+				return err
+			}
+
+			revisions, err := history.Log(cmd.Context(), hostsFile)
+			if err != nil {
+				return err
+			}
+			for _, rev := range revisions {
+				fmt.Printf("%s  %s  %-10s %s\n", rev.Hash[:8], rev.Time.Local().Format("2006-01-02 15:04:05"), rev.Author, rev.Message)
+			}
+			return nil
+		},
+	}
+}
+
+func newHistoryShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <rev>",
+		Short: "Print a version of the hosts file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, err := hostsPath(cmd)
+			if err != nil {
+				return err
+			}
+			snapshot, err := readSnapshot(cmd.Context(), args[0], hostsFile)
+			if err != nil {
+				return err
+			}
+			fmt.Print(snapshot.String())
+			return nil
+		},
+	}
+}
+
+func newHistoryCheckoutCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "checkout <rev>",
+		Short: "Restore a version of the hosts file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+			snapshot, err := readSnapshot(cmd.Context(), args[0], hostsFile)
+			if err != nil {
+				return err
+			}
+
+			changes := pkg.Diff(hosts, snapshot)
+			if snapshot.String() == hosts.String() && snapshot.Encoding() == hosts.Encoding() && snapshot.EOL() == hosts.EOL() {
+				fmt.Println("No changes made")
+				return nil
+			}
+			if err := writeHosts(cmd.Context(), hostsFile, hosts, snapshot); err != nil {
+				return err
+			}
+
+			fmt.Printf("Restored %s to %s\n", hostsFile, args[0])
+			for _, c := range changes {
+				fmt.Println(c)
+			}
+			return nil
+		},
+	}
+}
+
+func historyDir() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history"), nil
+}
+
+func openHistory() (pkg.History, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return pkg.History{}, err
+	}
+	history, err := pkg.OpenHistory(dir)
+	if errors.Is(err, pkg.ErrNoHistory) {
+		return pkg.History{}, fmt.Errorf("%s, run whosts history init", err)
+	}
+	return history, err
+}
+
+// readSnapshot parses the hosts file at path as of revision rev.
+func readSnapshot(ctx context.Context, rev, path string) (pkg.Hosts, error) {
+	history, err := openHistory()
+	if err != nil {
+		return pkg.Hosts{}, err
+	}
+	content, err := history.Show(ctx, rev, path)
+	if err != nil {
+		return pkg.Hosts{}, err
+	}
+	hosts, err := pkg.ParseEntries(bytes.NewReader(content))
+	if err != nil {
+		return pkg.Hosts{}, fmt.Errorf("parse %s at %s: %s", path, rev, err)
+	}
+	return hosts, nil
+}
+
+// recordHistory commits the hosts file at path to the history, if one
+// has been initialized. previous is the content of the file before the
+// write; if it differs from the last snapshot, the file was changed by
+// something other than whosts and it is committed first.
+func recordHistory(ctx context.Context, path string, previous []byte, event hookEvent) error {
+	dir, err := historyDir()
+	if err != nil {
+		return err
+	}
+	history, err := pkg.OpenHistory(dir)
+	if errors.Is(err, pkg.ErrNoHistory) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if previous != nil {
+		if _, err := history.Commit(ctx, path, previous, "external", "External change"); err != nil {
+			return err
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read hosts file: %s", err)
+	}
+	message := "whosts " + commandLine(os.Args[1:])
+	if len(event.Changes) > 0 {
+		lines := make([]string, 0, len(event.Changes))
+		for _, c := range event.Changes {
+			lines = append(lines, c.String())
+		}
+		message += "\n\n" + strings.Join(lines, "\n")
+	}
+	_, err = history.Commit(ctx, path, content, currentUser(), message)
+	return err
+}
+
+// recordExternalChange commits the hosts file at path to the history
+// after it was changed by something other than whosts.
+func recordExternalChange(ctx context.Context, path string) error {
+	history, err := openHistory()
+	if err != nil {
+		return err
+	}
+
+	// Writes by whosts commit to the history while holding the lock, so
+	// waiting for it keeps them from being recorded as external changes.
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+	unlock, err := pkg.Lock(lockCtx, path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read hosts file: %s", err)
+	}
+	message := fmt.Sprintf("External change detected at %s", time.Now().Format(time.RFC3339))
+	_, err = history.Commit(ctx, path, content, "external", message)
+	return err
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tifye/whosts/pkg"
+)
+
+// hookEvent is passed as JSON on stdin to the hooks run around a write.
+type hookEvent struct {
+	Stage   string       `json:"stage"`
+	Time    time.Time    `json:"time"`
+	File    string       `json:"file"`
+	OldFile string       `json:"old_file,omitempty"`
+	NewFile string       `json:"new_file"`
+	Changes []pkg.Change `json:"changes"`
+}
+
+// hookTimeout is how long a hook may run before it is killed. Hooks run
+// while the hosts file is unlocked, but the command making the write,
+// or the API request, waits for them, so a hook that hangs would
+// otherwise hold it up indefinitely. 20 seconds leaves room for slower
+// hooks such as reloading a DNS server or pushing to a remote.
+const hookTimeout = 20 * time.Second
+
+// runHooks runs each command with the event on stdin, stopping at the
+// first one that fails.
+func runHooks(ctx context.Context, commands []string, event hookEvent) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal hook event: %s", err)
+	}
+
+	for _, command := range commands {
+		if err := runHook(ctx, command, b, event); err != nil {
+			return fmt.Errorf("%s-hook %q: %s", event.Stage, command, err)
+		}
+	}
+	return nil
+}
+
+func runHook(ctx context.Context, command string, input []byte, event hookEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+
+	hook := shellCommand(ctx, command)
+	hook.Stdin = bytes.NewReader(input)
+	hook.Stdout = os.Stderr
+	hook.Stderr = os.Stderr
+	hook.Env = append(os.Environ(),
+		"WHOSTS_HOOK="+event.Stage,
+		"WHOSTS_FILE="+event.File,
+		"WHOSTS_OLD_FILE="+event.OldFile,
+		"WHOSTS_NEW_FILE="+event.NewFile,
+	)
+	err := hook.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", hookTimeout)
+	}
+	return err
+}
@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+var errHostsModified = errors.New("hosts file was modified since it was read")
+
+const (
+	lockTimeout = 10 * time.Second
+	keepBackups = 20
+)
+
+// hostsTargets returns the paths of the hosts files targeted by the
+// --hosts flags. A value of @name targets each file of the named target
+// group of the config.
+func hostsTargets(cmd *cobra.Command) ([]string, error) {
+	values, err := cmd.Flags().GetStringArray("hosts")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		cfg    config
+		loaded bool
+	)
+	targets := make([]string, 0, len(values))
+	seen := map[string]bool{}
+	for _, value := range values {
+		paths := []string{value}
+		if name, ok := strings.CutPrefix(value, "@"); ok {
+			if !loaded {
+				if cfg, err = loadConfig(); err != nil {
+					return nil, err
+				}
+				loaded = true
+			}
+			group, ok := cfg.Targets[name]
+			if !ok || len(group) == 0 {
+				return nil, fmt.Errorf("unknown target group %q", name)
+			}
+			paths = group
+		}
+		for _, path := range paths {
+			// The same file may be named by different paths, such as
+			// a relative and an absolute one.
+			key := filepath.Clean(path)
+			if abs, err := filepath.Abs(path); err == nil {
+				key = abs
+			}
+			if !seen[key] {
+				seen[key] = true
+				targets = append(targets, path)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no hosts file targeted")
+	}
+	return targets, nil
+}
+
+// hostsPath returns the path of the hosts file targeted by the --hosts
+// flags, for commands that work on a single file.
+func hostsPath(cmd *cobra.Command) (string, error) {
+	targets, err := hostsTargets(cmd)
+	if err != nil {
+		return "", err
+	}
+	if len(targets) > 1 {
+		return "", fmt.Errorf("%s works on a single hosts file, got %d", cmd.CommandPath(), len(targets))
+	}
+	return targets[0], nil
+}
+
+// forEachTarget runs fn for each hosts file targeted by the --hosts
+// flags. With more than one target the output of each is headed by its
+// path, and a failing target is reported without stopping the others.
+func forEachTarget(cmd *cobra.Command, fn func(path string) error) error {
+	targets, err := hostsTargets(cmd)
+	if err != nil {
+		return err
+	}
+	return runTargets(targets, fn)
+}
+
+func runTargets(targets []string, fn func(path string) error) error {
+	if len(targets) == 1 {
+		return fn(targets[0])
+	}
+
+	failed := 0
+	for i, path := range targets {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("==> %s <==\n", path)
+		if err := fn(path); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %s\n", path, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed on %d of %d hosts files", failed, len(targets))
+	}
+	return nil
+}
+
+// readHosts parses the hosts file targeted by the --hosts flag and
+// returns its path along with the parsed entries.
+func readHosts(cmd *cobra.Command) (string, pkg.Hosts, error) {
+	hostsFile, err := hostsPath(cmd)
+	if err != nil {
+		return "", pkg.Hosts{}, err
+	}
+
+	hosts, err := pkg.ReadFile(hostsFile)
+	if err != nil {
+		return "", pkg.Hosts{}, err
+	}
+
+	return hostsFile, hosts, nil
+}
+
+// writeHosts replaces the hosts file at path with after. All commands
+// that modify a hosts file go through here. before is the version that
+// after was derived from; if the file has changed since it was read the
+// write is refused instead of discarding those changes.
+//
+// The pre-hooks of the config run before the file is replaced and can
+// abort the write by failing, the post-hooks run after it. Hooks run
+// while the file is not locked, so a slow hook does not hold up other
+// writers. Finally the DNS resolver caches are flushed if --flush or the
+// config asks for it.
+//
+// Writes that remove or rewrite more entries than the guard of the config
+// allows need --yes or confirmation, which is asked before the file is
+// locked.
+func writeHosts(ctx context.Context, path string, before, after pkg.Hosts) error {
+	// Writes that change nothing are skipped, leaving no backup, audit
+	// record or history commit behind and running no hooks.
+	if after.String() == before.String() && after.Encoding() == before.Encoding() && after.EOL() == before.EOL() {
+		return nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	changes := pkg.Diff(before, after)
+	opts := writeOptionsFrom(ctx)
+	if err := cfg.Guard.limit().Check(before, changes); err != nil && !opts.yes {
+		affected := affectedList(changes)
+		if opts.confirm == nil {
+			return fmt.Errorf("%s: %w%s\nuse --yes to write it anyway", path, err, affected)
+		}
+		if !opts.confirm(fmt.Sprintf("%s: %s%s", path, err, affected)) {
+			return fmt.Errorf("aborted write: %s", err)
+		}
+	}
+
+	event := hookEvent{
+		Time:    time.Now(),
+		File:    path,
+		Changes: changes,
+	}
+
+	if len(cfg.Hooks.Pre) > 0 {
+		next, err := os.CreateTemp("", "whosts-*-"+filepath.Base(path))
+		if err != nil {
+			return fmt.Errorf("create temp file: %s", err)
+		}
+		next.Close()
+		defer os.Remove(next.Name())
+		if err := pkg.WriteFile(next.Name(), after); err != nil {
+			return err
+		}
+
+		event.Stage, event.OldFile, event.NewFile = "pre", path, next.Name()
+		if err := runHooks(ctx, cfg.Hooks.Pre, event); err != nil {
+			return fmt.Errorf("aborted write: %s", err)
+		}
+	}
+
+	backup, err := replaceHosts(ctx, path, before, after, event)
+	if err != nil {
+		return err
+	}
+
+	// The file has been written, so a failing post-hook is only reported.
+	event.Stage, event.OldFile, event.NewFile = "post", backup.Path(), path
+	if err := runHooks(ctx, cfg.Hooks.Post, event); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+	}
+
+	flush := cfg.Flush
+	if opts.flush != nil {
+		flush = *opts.flush
+	}
+	if flush {
+		for _, result := range pkg.FlushDNS(ctx, pkg.DefaultFlushers()) {
+			fmt.Fprintln(os.Stderr, result)
+		}
+	}
+	return nil
+}
+
+// replaceHosts locks the hosts file at path and, unless it has changed
+// since before was read, backs it up and replaces it with after. The
+// write is recorded in the audit log and the history while the lock is
+// held.
+func replaceHosts(ctx context.Context, path string, before, after pkg.Hosts, event hookEvent) (pkg.Backup, error) {
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+
+	unlock, err := pkg.Lock(lockCtx, path)
+	if err != nil {
+		return pkg.Backup{}, err
+	}
+	defer unlock()
+
+	current, err := pkg.ReadFile(path)
+	if err != nil {
+		return pkg.Backup{}, err
+	}
+	if current.String() != before.String() {
+		return pkg.Backup{}, fmt.Errorf("%s: %w, try again", path, errHostsModified)
+	}
+
+	// The previous content is kept for the history; a file that can not
+	// be read here has already failed to parse above.
+	previous, _ := os.ReadFile(path)
+
+	dir, err := backupDir()
+	if err != nil {
+		return pkg.Backup{}, err
+	}
+	backup, err := pkg.CreateBackup(path, dir, keepBackups)
+	if err != nil {
+		return pkg.Backup{}, fmt.Errorf("backup: %s", err)
+	}
+
+	if err := pkg.WriteFile(path, after); err != nil {
+		return pkg.Backup{}, err
+	}
+
+	if err := appendAudit(event); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: audit log: %s\n", err)
+	}
+	if err := recordHistory(ctx, path, previous, event); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: history: %s\n", err)
+	}
+	return backup, nil
+}
+
+// affectedList lists the first of the removed and rewritten entries of
+// a write refused by the guard, each on a line of its own.
+func affectedList(changes []pkg.Change) string {
+	const maxListed = 10
+	var b strings.Builder
+	listed := 0
+	for _, c := range changes {
+		if c.Kind == pkg.Added {
+			continue
+		}
+		if listed == maxListed {
+			b.WriteString("\n  ...")
+			break
+		}
+		fmt.Fprintf(&b, "\n  %s", c)
+		listed++
+	}
+	return b.String()
+}
+
+// dataDir is where whosts keeps its own files.
+func dataDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("user config dir: %s", err)
+	}
+	return filepath.Join(dir, "whosts"), nil
+}
+
+func auditLogPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// appendAudit records the write described by event in the audit log.
+func appendAudit(event hookEvent) error {
+	path, err := auditLogPath()
+	if err != nil {
+		return err
+	}
+	file, err := filepath.Abs(event.File)
+	if err != nil {
+		file = event.File
+	}
+	return pkg.AppendAudit(path, pkg.AuditRecord{
+		Time:    event.Time,
+		User:    currentUser(),
+		Command: os.Args,
+		File:    file,
+		Changes: event.Changes,
+	})
+}
+
+// currentUser returns the name of the OS user running whosts. Under sudo
+// it is the user that ran sudo rather than root.
+func currentUser() string {
+	if name := os.Getenv("SUDO_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+func backupDir() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "backups"), nil
+}
+
+type writeOptionsKey struct{}
+
+// writeOptions are the options of the root command that apply to
+// writeHosts, passed to it in the context.
+type writeOptions struct {
+	// flush overrides the flush setting of the config if set.
+	flush *bool
+	// yes skips the guard against changing too many entries.
+	yes bool
+	// confirm asks whether to go ahead with a write the guard refuses.
+	// Without it such writes fail.
+	confirm func(summary string) bool
+}
+
+func withWriteOptions(ctx context.Context, opts writeOptions) context.Context {
+	return context.WithValue(ctx, writeOptionsKey{}, opts)
+}
+
+func writeOptionsFrom(ctx context.Context) writeOptions {
+	opts, _ := ctx.Value(writeOptionsKey{}).(writeOptions)
+	return opts
+}
+
+// withoutConfirm keeps writeHosts from asking for confirmation, for
+// writes made while the terminal is in use by something else.
+func withoutConfirm(ctx context.Context) context.Context {
+	opts := writeOptionsFrom(ctx)
+	opts.confirm = nil
+	return withWriteOptions(ctx, opts)
+}
+
+// confirmWrite asks on the terminal whether to go ahead with a write.
+func confirmWrite(summary string) bool {
+	fmt.Fprintf(os.Stderr, "%s\nWrite anyway? [y/N]: ", summary)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+type syncK8sOptions struct {
+	section  string
+	from     string
+	fromJSON string
+	ip       netip.Addr
+	dryRun   bool
+}
+
+func newSyncK8sCommand() *cobra.Command {
+	opts := &syncK8sOptions{}
+	cmd := &cobra.Command{
+		Use:   "k8s",
+		Short: "Reconcile a section with the hosts of Kubernetes Ingresses and LoadBalancer Services",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var objects []pkg.K8sObject
+			var err error
+			if opts.fromJSON != "" {
+				objects, err = readK8sInput(opts.fromJSON)
+			} else {
+				objects, err = readK8sManifests(opts.from)
+			}
+			if err != nil {
+				return err
+			}
+
+			return syncSection(cmd, opts.section, k8sEntries(objects, opts.ip), opts.dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.section, "section", "k8s", "Section to reconcile")
+	cmd.Flags().StringVar(&opts.from, "from", "", "Manifest file, or directory of manifest files, to read")
+	cmd.Flags().StringVar(&opts.fromJSON, "from-json", "", "Read the JSON output of kubectl get from this file, or - for stdin")
+	cmd.Flags().Var(addrValue{&opts.ip}, "ip", "Address of the cluster load balancer the hosts point at")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry", false, "Dry run command and print out the changes that would have been made")
+	cmd.MarkFlagsOneRequired("from", "from-json")
+	cmd.MarkFlagsMutuallyExclusive("from", "from-json")
+	cmd.MarkFlagRequired("ip")
+
+	return cmd
+}
+
+// readK8sManifests reads the objects of the manifest file at path, or of
+// every YAML and JSON file in the directory at path.
+func readK8sManifests(path string) ([]pkg.K8sObject, error) {
+	objects := make([]pkg.K8sObject, 0)
+	err := filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if name != path && !slices.Contains([]string{".yaml", ".yml", ".json"}, filepath.Ext(name)) {
+			return nil
+		}
+
+		objs, err := readK8sInput(name)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, objs...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read manifests: %s", err)
+	}
+	return objects, nil
+}
+
+// readK8sInput reads the objects of a file, or stdin if name is "-",
+// containing one or more YAML or JSON documents.
+func readK8sInput(name string) ([]pkg.K8sObject, error) {
+	f, err := openInput(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	objects, err := pkg.DecodeK8sObjects(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %s", name, err)
+	}
+	return objects, nil
+}
+
+// k8sEntries returns an entry pointing at ip for every host name served
+// by an Ingress or LoadBalancer Service, warning about the names that
+// are skipped.
+func k8sEntries(objects []pkg.K8sObject, ip netip.Addr) []pkg.Entry {
+	entries, errs := pkg.K8sEntries(objects, ip)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "skipping %s\n", err)
+	}
+	return entries
+}
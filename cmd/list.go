@@ -3,35 +3,80 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tifye/whosts/pkg"
 )
 
+type listOptions struct {
+	tags    map[string]string
+	display string
+}
+
 func newListCommand() *cobra.Command {
+	opts := &listOptions{}
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all entries",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			hostsFile, err := cmd.Flags().GetString("hosts")
-			if err != nil {
-				return err
+			var display func(string) (string, error)
+			switch opts.display {
+			case "":
+			case "ascii":
+				display = pkg.HostToASCII
+			case "unicode":
+				display = pkg.HostToUnicode
+			default:
+				return fmt.Errorf("unknown display mode %q, expected ascii or unicode", opts.display)
 			}
 
-			file, err := os.Open(hostsFile)
-			if err != nil {
-				return fmt.Errorf("open file: %s", err)
-			}
-			defer file.Close()
-
-			hosts, err := pkg.ParseEntries(file)
-			if err != nil {
-				return err
-			}
-
-			fmt.Println(hosts.String())
-			return nil
+			return forEachTarget(cmd, func(hostsFile string) error {
+				return listEntries(hostsFile, opts, display)
+			})
 		},
 	}
+
+	cmd.Flags().StringToStringVar(&opts.tags, "tag", nil, "List entries with matching tag key=value")
+	cmd.Flags().StringVar(&opts.display, "display", "", "Show host names as ascii (punycode) or unicode instead of as written")
+
 	return cmd
 }
+
+func listEntries(hostsFile string, opts *listOptions, display func(string) (string, error)) error {
+	// Show what can be parsed rather than failing on a single bad line.
+	hosts, parseErrs, err := pkg.ReadFileLenient(hostsFile)
+	if err != nil {
+		return err
+	}
+	for _, parseErr := range parseErrs {
+		fmt.Fprintf(os.Stderr, "warning: %s: %s\n", hostsFile, parseErr)
+	}
+
+	now := time.Now()
+	entries := hosts.Entries()
+	for _, i := range hosts.Find(pkg.WithTags(opts.tags), pkg.WithDisabled()) {
+		entry := entries[i]
+		if display != nil {
+			entry = displayNames(entry, display)
+		}
+		if expires, ok := entry.Expires(); ok {
+			fmt.Printf("%s (%s)\n", entry, lifetime(expires, now))
+			continue
+		}
+		fmt.Println(entry)
+	}
+	return nil
+}
+
+// displayNames returns entry with its names converted by display. Names
+// that can not be converted are left as they are.
+func displayNames(entry pkg.Entry, display func(string) (string, error)) pkg.Entry {
+	entry.Host, _ = display(entry.Host)
+	aliases := make([]string, len(entry.Aliases))
+	for i, alias := range entry.Aliases {
+		aliases[i], _ = display(alias)
+	}
+	entry.Aliases = aliases
+	return entry
+}
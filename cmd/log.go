@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+type logOptions struct {
+	ip    netip.Addr
+	host  string
+	user  string
+	since string
+	until string
+}
+
+func newLogCommand() *cobra.Command {
+	opts := &logOptions{}
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show the audit log of changes made to hosts files",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := pkg.AuditFilter{Host: opts.host, IP: opts.ip, User: opts.user}
+			now := time.Now()
+			var err error
+			if opts.since != "" {
+				if filter.Since, err = parseTimeOrAgo(opts.since, now); err != nil {
+					return err
+				}
+			}
+			if opts.until != "" {
+				if filter.Until, err = parseTimeOrAgo(opts.until, now); err != nil {
+					return err
+				}
+			}
+
+			path, err := auditLogPath()
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				fmt.Println("No changes recorded")
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("open audit log: %s", err)
+			}
+			defer f.Close()
+
+			records, err := pkg.ReadAudit(f)
+			if err != nil {
+				return fmt.Errorf("read %s: %s", path, err)
+			}
+
+			for _, record := range records {
+				if !filter.Match(record) {
+					continue
+				}
+				printAuditRecord(record)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Var(addrValue{&opts.ip}, "ip", "Only show changes to entries with this IP")
+	cmd.Flags().StringVar(&opts.host, "host", "", "Only show changes to entries with this host name")
+	cmd.Flags().StringVar(&opts.user, "user", "", "Only show changes made by this user")
+	cmd.Flags().StringVar(&opts.since, "since", "", "Only show changes made at or after this time, or this long ago such as 24h")
+	cmd.Flags().StringVar(&opts.until, "until", "", "Only show changes made at or before this time, or this long ago such as 24h")
+
+	return cmd
+}
+
+func printAuditRecord(record pkg.AuditRecord) {
+	fmt.Printf("%s  %s  %s\n", record.Time.Local().Format("2006-01-02 15:04:05"), record.User, record.File)
+	fmt.Printf("    $ %s\n", commandLine(record.Command))
+	if len(record.Changes) == 0 {
+		fmt.Println("    (no entries changed)")
+	}
+	for _, change := range record.Changes {
+		fmt.Printf("    %s\n", change)
+	}
+	fmt.Println()
+}
+
+// commandLine joins args, quoting the ones that need it.
+func commandLine(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// parseTimeOrAgo parses s as a time, or as a duration before now.
+func parseTimeOrAgo(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return parseTime(s)
+}
@@ -1,36 +1,146 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
 )
 
 func newOpenCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "open",
-		Short: "Opens the hosts file in notepad",
+		Short: "Edit the hosts file in $VISUAL or $EDITOR, validating it before it is saved",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			hostsFile, err := cmd.Flags().GetString("hosts")
+			hostsFile, hosts, err := readHosts(cmd)
 			if err != nil {
 				return err
 			}
 
-			execCmd := exec.Command("notepad", hostsFile)
-			err = execCmd.Start()
-			if err, ok := err.(*exec.ExitError); ok {
-				return fmt.Errorf("failed to open: %s", err)
+			tmp, err := os.CreateTemp("", "whosts-*-"+filepath.Base(hostsFile))
+			if err != nil {
+				return fmt.Errorf("create temp file: %s", err)
 			}
+			tmp.Close()
+			defer os.Remove(tmp.Name())
 
-			err = execCmd.Process.Release()
-			if err != nil {
-				return fmt.Errorf("err proc release: %s", err)
+			if err := pkg.WriteFile(tmp.Name(), hosts); err != nil {
+				return err
 			}
 
-			return nil
+			stdin := bufio.NewReader(os.Stdin)
+			for {
+				if err := runEditor(cmd.Context(), tmp.Name()); err != nil {
+					return err
+				}
+
+				edited, parseErrs, err := pkg.ReadFileLenient(tmp.Name())
+				if err != nil {
+					return err
+				}
+				warnings := edited.Lint()
+				for _, warning := range warnings {
+					fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+				}
+				for _, parseErr := range parseErrs {
+					fmt.Fprintf(os.Stderr, "error: %s\n", parseErr)
+				}
+				if len(parseErrs) > 0 || len(warnings) > 0 {
+					// Files with warnings can be saved anyway, ones
+					// that do not parse cannot.
+					answer, err := promptReedit(stdin, len(parseErrs) == 0)
+					if err != nil {
+						return err
+					}
+					switch answer {
+					case answerEdit:
+						continue
+					case answerExit:
+						fmt.Println("Aborted, the hosts file was not changed")
+						return nil
+					}
+				}
+
+				if edited.String() == hosts.String() && edited.Encoding() == hosts.Encoding() && edited.EOL() == hosts.EOL() {
+					fmt.Println("No changes made")
+					return nil
+				}
+				if err := writeHosts(cmd.Context(), hostsFile, hosts, edited); err != nil {
+					return err
+				}
+				fmt.Printf("Saved %s\n", hostsFile)
+				return nil
+			}
 		},
 	}
 
 	return cmd
 }
+
+// editor returns the command line of the user's editor.
+func editor() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+func runEditor(ctx context.Context, path string) error {
+	args := editor()
+	editCmd := exec.CommandContext(ctx, args[0], append(args[1:], path)...)
+	editCmd.Stdin = os.Stdin
+	editCmd.Stdout = os.Stdout
+	editCmd.Stderr = os.Stderr
+	if err := editCmd.Run(); err != nil {
+		return fmt.Errorf("run editor %s: %s", args[0], err)
+	}
+	return nil
+}
+
+const (
+	answerEdit = "edit"
+	answerSave = "save"
+	answerExit = "exit"
+)
+
+// promptReedit asks whether to edit the file again, save it if canSave
+// is set, or abort, and returns the answer.
+func promptReedit(r *bufio.Reader, canSave bool) (string, error) {
+	prompt := "What now? Edit (a)gain or e(x)it without saving: "
+	if canSave {
+		prompt = "What now? Edit (a)gain, (s)ave anyway or e(x)it without saving: "
+	}
+	for {
+		fmt.Print(prompt)
+		answer, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || answer == "") {
+			return "", fmt.Errorf("read answer: %s", err)
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "a", "e", "again", "edit":
+			return answerEdit, nil
+		case "s", "save":
+			if canSave {
+				return answerSave, nil
+			}
+		case "x", "exit", "q", "quit":
+			return answerExit, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("read answer: %s", err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+type protectOptions struct {
+	entryFilter
+	remove bool
+}
+
+func newProtectCommand() *cobra.Command {
+	opts := &protectOptions{}
+	cmd := &cobra.Command{
+		Use:   "protect",
+		Short: "Protect entries matching passed filters from remove, set, apply and dedupe without --force",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			updated := hosts.Clone()
+			entries := updated.Entries()
+			matched := updated.Find(opts.filters()...)
+			for _, i := range matched {
+				entry := entries[i]
+				entry.SetProtected(!opts.remove)
+				if opts.remove && entry.Protected() {
+					fmt.Printf("%s is always protected\n", entry)
+				}
+				if err := updated.Set(i, entry); err != nil {
+					return err
+				}
+			}
+			if len(matched) == 0 {
+				fmt.Println("No matching entries")
+				return nil
+			}
+
+			changes := pkg.Diff(hosts, updated)
+			if len(changes) > 0 {
+				if err := writeHosts(cmd.Context(), hostsFile, hosts, updated); err != nil {
+					return err
+				}
+			}
+			for _, c := range changes {
+				fmt.Println(c)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Var(addrValue{&opts.ip}, "ip", "Protect entries with matching IP")
+	cmd.Flags().StringVar(&opts.host, "host", "", "Protect entries with matching host name")
+	cmd.Flags().StringVar(&opts.comment, "comment", "", "Protect entries with matching comment")
+	cmd.Flags().StringVar(&opts.section, "section", "", "Protect entries in the section")
+	cmd.Flags().StringToStringVar(&opts.tags, "tag", nil, "Protect entries with matching tag key=value")
+	cmd.MarkFlagsOneRequired("ip", "host", "comment", "section", "tag")
+	cmd.Flags().BoolVar(&opts.remove, "remove", false, "Remove the protection of matching entries instead")
+
+	return cmd
+}
+
+// printSkipped lists the protected entries a command left untouched.
+func printSkipped(skipped []pkg.Entry) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Println("Skipped protected entries, use --force to change them:")
+	for _, e := range skipped {
+		fmt.Printf("  %s\n", e)
+	}
+}
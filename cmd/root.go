@@ -19,8 +19,33 @@ func newRootCommand() *cobra.Command {
 		},
 	}
 
-	cmd.PersistentFlags().String("hosts", pkg.DefaultHostsPath, "Path to hosts file to target")
+	cmd.PersistentFlags().StringArray("hosts", []string{pkg.DefaultHostsPath}, "Path to hosts file to target, or @name for a target group of the config. Repeat to target several files")
 	cmd.MarkPersistentFlagFilename("hosts")
+	cmd.PersistentFlags().Bool("flush", false, "Flush the DNS resolver caches after writing the hosts file, overriding the flush setting of the config")
+
+	cmd.PersistentFlags().Bool("yes", false, "Write changes larger than the guard of the config allows without asking")
+
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		var opts writeOptions
+		// Only an explicit --flush overrides the config.
+		if f := cmd.Flags().Lookup("flush"); f != nil && f.Changed {
+			flush, err := cmd.Flags().GetBool("flush")
+			if err != nil {
+				return err
+			}
+			opts.flush = &flush
+		}
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			return err
+		}
+		opts.yes = yes
+		if isTerminal(os.Stdin) {
+			opts.confirm = confirmWrite
+		}
+		cmd.SetContext(withWriteOptions(cmd.Context(), opts))
+		return nil
+	}
 
 	return cmd
 }
@@ -47,6 +72,23 @@ func addCommands(root *cobra.Command) {
 		newAddCommand(),
 		newOpenCommand(),
 		newRemoveCommand(),
+		newDedupeCommand(),
+		newProtectCommand(),
+		newSetCommand(),
+		newTUICommand(),
+		newWatchCommand(),
+		newServeCommand(),
+		newDNSCommand(),
+		newPlanCommand(),
+		newApplyCommand(),
+		newVarCommand(),
+		newRetargetCommand(),
+		newGCCommand(),
+		newSyncCommand(),
+		newFmtCommand(),
+		newLogCommand(),
+		newBatchCommand(),
+		newHistoryCommand(),
 	)
 }
 
@@ -0,0 +1,544 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+type serveOptions struct {
+	listen     string
+	token      string
+	gcInterval time.Duration
+	gcDisable  bool
+}
+
+func newServeCommand() *cobra.Command {
+	opts := &serveOptions{}
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve an HTTP API for managing entries",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, err := hostsPath(cmd)
+			if err != nil {
+				return err
+			}
+
+			token := opts.token
+			if token == "" {
+				token = os.Getenv("WHOSTS_TOKEN")
+			}
+			if token == "" {
+				b := make([]byte, 32)
+				if _, err := rand.Read(b); err != nil {
+					return fmt.Errorf("generate token: %s", err)
+				}
+				token = hex.EncodeToString(b)
+				fmt.Fprintf(os.Stderr, "Generated API token: %s\n", token)
+			}
+
+			listener, err := listen(opts.listen)
+			if err != nil {
+				return err
+			}
+
+			// Writes made through the API can not be confirmed on the
+			// terminal, so ones the guard refuses fail unless --yes is set.
+			writeOpts := writeOptionsFrom(cmd.Context())
+			writeOpts.confirm = nil
+			server := newServer(hostsFile, token, writeOpts)
+			if opts.gcInterval > 0 {
+				go server.collectExpired(cmd.Context(), opts.gcInterval, opts.gcDisable)
+			}
+
+			srv := &http.Server{
+				Handler:           server.routes(),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			go func() {
+				<-cmd.Context().Done()
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = srv.Shutdown(ctx)
+			}()
+
+			fmt.Fprintf(os.Stderr, "Listening on %s\n", listener.Addr())
+			if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("serve: %s", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.listen, "listen", "127.0.0.1:7777", "Address to listen on, either host:port or unix:/path/to/socket")
+	cmd.Flags().StringVar(&opts.token, "token", "", "Bearer token clients must send, defaults to $WHOSTS_TOKEN or a generated token")
+	cmd.Flags().DurationVar(&opts.gcInterval, "gc-interval", time.Minute, "Interval at which expired entries are removed, 0 to never remove them")
+	cmd.Flags().BoolVar(&opts.gcDisable, "gc-disable", false, "Disable expired entries instead of removing them")
+
+	return cmd
+}
+
+func listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		// Remove a socket left behind by a previous run.
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(path)
+		}
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, fmt.Errorf("listen: %s", err)
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("chmod socket: %s", err)
+		}
+		return listener, nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen: %s", err)
+	}
+	return listener, nil
+}
+
+type server struct {
+	path  string
+	token string
+	// opts are the write options of the serve command, applied to every
+	// write made by the server.
+	opts writeOptions
+
+	// mu serializes modifications made through the API, the file lock
+	// taken by writeHosts guards against other processes.
+	mu sync.Mutex
+}
+
+func newServer(path, token string, opts writeOptions) *server {
+	return &server{path: path, token: token, opts: opts}
+}
+
+// apiEntry is an entry along with its index, which identifies it in
+// update requests.
+type apiEntry struct {
+	Index int `json:"index"`
+	pkg.Entry
+	// Tags are the tags of the entry's comment. When sent by a client
+	// they replace the tags of the comment.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+func newAPIEntry(index int, entry pkg.Entry) apiEntry {
+	return apiEntry{Index: index, Entry: entry, Tags: entry.Tags()}
+}
+
+// apiUpdate is the body of an update request. Old is the entry as the
+// client last saw it, such as returned by GET /entries, and New is what
+// it is replaced with. The index fields are ignored.
+type apiUpdate struct {
+	Old apiEntry `json:"old"`
+	New apiEntry `json:"new"`
+}
+
+// apiRemoved is the response of a remove request. Skipped are the
+// protected entries that matched but were kept, as force was not set.
+type apiRemoved struct {
+	Removed []pkg.Entry `json:"removed"`
+	Skipped []pkg.Entry `json:"skipped"`
+}
+
+type apiSection struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /entries", s.handleListEntries)
+	mux.HandleFunc("POST /entries", s.handleAddEntry)
+	mux.HandleFunc("PUT /entries/{index}", s.handleUpdateEntry)
+	mux.HandleFunc("DELETE /entries", s.handleRemoveEntries)
+	mux.HandleFunc("GET /sections", s.handleListSections)
+	mux.HandleFunc("GET /backups", s.handleListBackups)
+	mux.HandleFunc("POST /backups/{name}/restore", s.handleRestoreBackup)
+	return s.authenticate(mux)
+}
+
+func (s *server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, apiError{"missing or invalid bearer token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) handleListEntries(w http.ResponseWriter, r *http.Request) {
+	filter, err := queryFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	hosts, err := pkg.ReadFile(s.path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	entries := make([]apiEntry, 0)
+	for _, i := range hosts.Find(append(filter.filters(), pkg.WithDisabled())...) {
+		entries = append(entries, newAPIEntry(i, hosts.Entries()[i]))
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *server) handleAddEntry(w http.ResponseWriter, r *http.Request) {
+	entry, err := decodeEntry(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var stored apiEntry
+	err = s.update(r.Context(), func(hosts *pkg.Hosts) error {
+		if err := bindEntry(*hosts, &entry); err != nil {
+			return err
+		}
+		if err := hosts.AddEntry(entry); err != nil {
+			return err
+		}
+		index := len(hosts.Entries()) - 1
+		stored = newAPIEntry(index, hosts.Entries()[index])
+		return nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, stored)
+}
+
+func (s *server) handleUpdateEntry(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid index: %s", r.PathValue("index")))
+		return
+	}
+
+	var update apiUpdate
+	if err := decodeJSON(r, &update); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode update: %s", err))
+		return
+	}
+	entry, err := checkEntry(update.New)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Protected entries are only changed with force=true.
+	force := r.URL.Query().Get("force") == "true"
+
+	// Indexes shift as entries are added and removed, so the update is
+	// refused unless the entry at index is still the one the client saw.
+	var stored apiEntry
+	err = s.update(r.Context(), func(hosts *pkg.Hosts) error {
+		if index < 0 || index >= len(hosts.Entries()) {
+			return errNotFound
+		}
+		if current := hosts.Entries()[index]; current.Key() != update.Old.Key() || current.Section != update.Old.Section {
+			return fmt.Errorf("entry %d: %w", index, errEntryChanged)
+		}
+		if hosts.Entries()[index].Protected() && !force {
+			return fmt.Errorf("entry %d: %w", index, errProtected)
+		}
+		if err := bindEntry(*hosts, &entry); err != nil {
+			return err
+		}
+		if err := hosts.Set(index, entry); err != nil {
+			return err
+		}
+		stored = newAPIEntry(index, hosts.Entries()[index])
+		return nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stored)
+}
+
+func (s *server) handleRemoveEntries(w http.ResponseWriter, r *http.Request) {
+	filter, err := queryFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	duplicatesOnly := r.URL.Query().Get("duplicates-only") == "true"
+	force := r.URL.Query().Get("force") == "true"
+	filters := filter.filters()
+	if len(filters) == 0 {
+		if !duplicatesOnly {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("at least one filter is required"))
+			return
+		}
+		filters = append(filters, pkg.WithAll())
+	}
+
+	resp := apiRemoved{Removed: []pkg.Entry{}, Skipped: []pkg.Entry{}}
+	err = s.update(r.Context(), func(hosts *pkg.Hosts) error {
+		removed, skipped := removeMatching(hosts, duplicatesOnly, force, filters...)
+		resp.Removed = append(resp.Removed, removed...)
+		resp.Skipped = append(resp.Skipped, skipped...)
+		return nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) handleListSections(w http.ResponseWriter, r *http.Request) {
+	hosts, err := pkg.ReadFile(s.path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sections := make([]apiSection, 0)
+	for _, name := range hosts.Sections() {
+		var count int
+		for _, e := range hosts.Entries() {
+			if e.Section == name {
+				count++
+			}
+		}
+		sections = append(sections, apiSection{Name: name, Entries: count})
+	}
+	writeJSON(w, http.StatusOK, sections)
+}
+
+func (s *server) handleListBackups(w http.ResponseWriter, r *http.Request) {
+	dir, err := backupDir()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	backups, err := pkg.ListBackups(s.path, dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, backups)
+}
+
+func (s *server) handleRestoreBackup(w http.ResponseWriter, r *http.Request) {
+	dir, err := backupDir()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	backups, err := pkg.ListBackups(s.path, dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	name := r.PathValue("name")
+	for _, b := range backups {
+		if b.Name != name {
+			continue
+		}
+
+		restored, err := pkg.ReadFile(b.Path())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		err = s.update(r.Context(), func(hosts *pkg.Hosts) error {
+			*hosts = restored
+			return nil
+		})
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, b)
+		return
+	}
+	writeError(w, http.StatusNotFound, errNotFound)
+}
+
+var (
+	errNotFound     = errors.New("not found")
+	errEntryChanged = errors.New("entry was changed since it was read")
+	errProtected    = errors.New("entry is protected, use force=true to change it")
+)
+
+// collectExpired periodically removes or disables expired entries until
+// ctx is done.
+func (s *server) collectExpired(ctx context.Context, interval time.Duration, disable bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			var expired []pkg.Entry
+			err := s.update(ctx, func(hosts *pkg.Hosts) error {
+				expired = collectExpired(hosts, now, disable)
+				return nil
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "gc: %s\n", err)
+				continue
+			}
+			for _, e := range expired {
+				fmt.Fprintf(os.Stderr, "gc: expired %s\n", e)
+			}
+		}
+	}
+}
+
+// update applies fn to the current hosts file and writes the result.
+func (s *server) update(ctx context.Context, fn func(hosts *pkg.Hosts) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hosts, err := pkg.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	updated := hosts.Clone()
+	if err := fn(&updated); err != nil {
+		return err
+	}
+	if updated.String() == hosts.String() {
+		return nil
+	}
+	return writeHosts(withWriteOptions(ctx, s.opts), s.path, hosts, updated)
+}
+
+func queryFilter(r *http.Request) (entryFilter, error) {
+	query := r.URL.Query()
+	filter := entryFilter{
+		host:      query.Get("host"),
+		comment:   query.Get("comment"),
+		section:   query.Get("section"),
+		noComment: query.Get("no-comment") == "true",
+	}
+	if ip := query.Get("ip"); ip != "" {
+		parsed, err := parseIP(ip)
+		if err != nil {
+			return entryFilter{}, err
+		}
+		filter.ip = parsed
+	}
+	for _, tag := range query["tag"] {
+		key, value, err := pkg.ParseTag(tag)
+		if err != nil {
+			return entryFilter{}, err
+		}
+		if filter.tags == nil {
+			filter.tags = map[string]string{}
+		}
+		filter.tags[key] = value
+	}
+	return filter, nil
+}
+
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func decodeEntry(r *http.Request) (pkg.Entry, error) {
+	var entry apiEntry
+	if err := decodeJSON(r, &entry); err != nil {
+		return pkg.Entry{}, fmt.Errorf("decode entry: %s", err)
+	}
+	return checkEntry(entry)
+}
+
+// checkEntry validates an entry sent by a client and normalizes its
+// comment and tags.
+func checkEntry(sent apiEntry) (pkg.Entry, error) {
+	entry := sent.Entry
+	if !entry.IP.IsValid() && entry.Var == "" {
+		return pkg.Entry{}, fmt.Errorf("ip or var is required")
+	}
+	if entry.Host == "" || strings.ContainsAny(entry.Host, " \t") {
+		return pkg.Entry{}, fmt.Errorf("host must be a single non-empty name")
+	}
+	for _, name := range entry.Names() {
+		if err := pkg.ValidateHost(name); err != nil {
+			return pkg.Entry{}, err
+		}
+	}
+	if entry.Comment != "" && !strings.HasPrefix(entry.Comment, "#") {
+		entry.Comment = "# " + entry.Comment
+	}
+	if sent.Tags != nil {
+		if err := entry.SetTags(sent.Tags); err != nil {
+			return pkg.Entry{}, err
+		}
+	}
+	return entry, nil
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, errNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errProtected):
+		return http.StatusForbidden
+	case errors.Is(err, pkg.ErrUndefinedVariable), errors.Is(err, pkg.ErrInvalidHost), errors.Is(err, pkg.ErrInvalidIP):
+		return http.StatusBadRequest
+	case errors.Is(err, errHostsModified), errors.Is(err, errEntryChanged):
+		return http.StatusConflict
+	case errors.Is(err, pkg.ErrTooManyChanges):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, pkg.ErrLocked):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, apiError{err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+func newSetCommand() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "set <ip|@variable> <host>",
+		Short: "Point a host at an address, updating its entries or adding one",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+				return fmt.Errorf("accepts 2 positional args: <ip|@variable> <host>")
+			}
+			if !strings.HasPrefix(args[0], "@") {
+				if _, err := parseIP(args[0]); err != nil {
+					return err
+				}
+			}
+			return pkg.ValidateHost(args[1])
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return forEachTarget(cmd, func(hostsFile string) error {
+				return setHost(cmd, hostsFile, args[0], args[1], force)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Also change protected entries")
+
+	return cmd
+}
+
+// setHost points host at target in the hosts file at hostsFile.
+// Protected entries are skipped unless force is set.
+func setHost(cmd *cobra.Command, hostsFile, target, host string, force bool) error {
+	hosts, err := pkg.ReadFile(hostsFile)
+	if err != nil {
+		return err
+	}
+	ip, name, err := resolveIP(hosts, target)
+	if err != nil {
+		return err
+	}
+
+	updated := hosts.Clone()
+	skipped, err := updated.PointHost(host, ip, name, force)
+	if err != nil {
+		return err
+	}
+	defer printSkipped(skipped)
+
+	changes := pkg.Diff(hosts, updated)
+	if len(changes) == 0 {
+		if len(skipped) == 0 {
+			fmt.Printf("%s already points at %s\n", host, ip)
+		}
+		return nil
+	}
+	if err := writeHosts(cmd.Context(), hostsFile, hosts, updated); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	return nil
+}
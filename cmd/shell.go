@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+)
+
+// shellCommand returns a command running command through the shell of
+// the current OS.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
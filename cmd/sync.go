@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+func newSyncCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Reconcile a managed section with entries from another source",
+	}
+	cmd.AddCommand(newSyncDockerCommand(), newSyncK8sCommand(), newSyncTargetsCommand())
+	return cmd
+}
+
+type syncTargetsOptions struct {
+	section string
+	dryRun  bool
+}
+
+func newSyncTargetsCommand() *cobra.Command {
+	opts := &syncTargetsOptions{}
+	cmd := &cobra.Command{
+		Use:   "targets",
+		Short: "Make the hosts files targeted by --hosts match the first of them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targets, err := hostsTargets(cmd)
+			if err != nil {
+				return err
+			}
+			if len(targets) < 2 {
+				return fmt.Errorf("needs at least two hosts files, repeat --hosts or use a target group")
+			}
+
+			source, err := pkg.ReadFile(targets[0])
+			if err != nil {
+				return err
+			}
+			return runTargets(targets[1:], func(hostsFile string) error {
+				return syncTarget(cmd, hostsFile, source, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.section, "section", "", "Only sync this section, leaving the rest of the files untouched")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry", false, "Print the changes without writing them")
+
+	return cmd
+}
+
+// syncTarget makes the hosts file at hostsFile match source, keeping
+// its own encoding and line endings.
+func syncTarget(cmd *cobra.Command, hostsFile string, source pkg.Hosts, opts *syncTargetsOptions) error {
+	hosts, err := pkg.ReadFile(hostsFile)
+	if err != nil {
+		return err
+	}
+
+	if opts.section != "" {
+		updated := hosts.Clone()
+		changes := updated.ReplaceSection(opts.section, source.SectionEntries(opts.section))
+		printSync(opts.section, changes)
+		if len(changes) == 0 || opts.dryRun {
+			return nil
+		}
+		return writeHosts(cmd.Context(), hostsFile, hosts, updated)
+	}
+
+	updated := source.Clone()
+	updated.SetEncoding(hosts.Encoding())
+	updated.SetEOL(hosts.EOL())
+	if updated.String() == hosts.String() {
+		fmt.Println("Up to date.")
+		return nil
+	}
+	changes := pkg.Diff(hosts, updated)
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	if opts.dryRun {
+		return nil
+	}
+	return writeHosts(cmd.Context(), hostsFile, hosts, updated)
+}
+
+// syncSection replaces the entries of the named section with entries,
+// reports the changes and, unless dryRun is set, writes them.
+func syncSection(cmd *cobra.Command, section string, entries []pkg.Entry, dryRun bool) error {
+	hostsFile, hosts, err := readHosts(cmd)
+	if err != nil {
+		return err
+	}
+
+	updated := hosts.Clone()
+	changes := updated.ReplaceSection(section, entries)
+	printSync(section, changes)
+	if len(changes) == 0 || dryRun {
+		return nil
+	}
+
+	return writeHosts(cmd.Context(), hostsFile, hosts, updated)
+}
+
+func printSync(section string, changes []pkg.Change) {
+	if len(changes) == 0 {
+		fmt.Printf("Section %q is up to date.\n", section)
+		return
+	}
+
+	var added, updated, stale int
+	for _, c := range changes {
+		switch c.Kind {
+		case pkg.Added:
+			added++
+			fmt.Printf("  added    %s\n", c.New)
+		case pkg.Changed:
+			updated++
+			fmt.Printf("  updated  %s\n        -> %s\n", c.Old, c.New)
+		case pkg.Removed:
+			stale++
+			fmt.Printf("  stale    %s\n", c.Old)
+		}
+	}
+	fmt.Printf("\nSection %q: %d added, %d updated, %d stale.\n", section, added, updated, stale)
+}
+
+// openInput opens the named file, or stdin if name is "-".
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
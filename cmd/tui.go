@@ -0,0 +1,583 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+func newTUICommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Browse and edit entries interactively",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			p := tea.NewProgram(
+				newTUIModel(withoutConfirm(cmd.Context()), hostsFile, hosts),
+				tea.WithAltScreen(),
+				tea.WithContext(cmd.Context()),
+			)
+			if _, err := p.Run(); err != nil {
+				return fmt.Errorf("run tui: %s", err)
+			}
+
+			return nil
+		},
+	}
+	return cmd
+}
+
+type tuiMode int
+
+const (
+	tuiBrowse tuiMode = iota
+	tuiFilter
+	tuiEdit
+	tuiReview
+)
+
+type tuiGrouping int
+
+const (
+	tuiGroupNone tuiGrouping = iota
+	tuiGroupSection
+	tuiGroupIP
+)
+
+func (g tuiGrouping) String() string {
+	switch g {
+	case tuiGroupSection:
+		return "section"
+	case tuiGroupIP:
+		return "ip"
+	default:
+		return "none"
+	}
+}
+
+// tuiRow is either a group header or an entry, referenced by its index
+// in the edited hosts.
+type tuiRow struct {
+	header string
+	index  int
+}
+
+var (
+	tuiTitleStyle    = lipgloss.NewStyle().Bold(true)
+	tuiHeaderStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	tuiSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	tuiDisabledStyle = lipgloss.NewStyle().Faint(true)
+	tuiHelpStyle     = lipgloss.NewStyle().Faint(true)
+	tuiErrorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	tuiAddedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	tuiRemovedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	tuiChangedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+)
+
+type tuiModel struct {
+	ctx      context.Context
+	path     string
+	original pkg.Hosts
+	hosts    pkg.Hosts
+
+	mode     tuiMode
+	grouping tuiGrouping
+	filter   textinput.Model
+
+	// Edit form for the IP, host and comment of an entry. editing is
+	// the index of the entry being edited, or -1 for a new entry.
+	inputs  []textinput.Model
+	focus   int
+	editing int
+
+	rows     []tuiRow
+	cursor   int
+	offset   int
+	height   int
+	status   string
+	err      error
+	quitting bool
+	// confirmKey is the key to press again to change the protected entry
+	// at confirmIndex.
+	confirmKey   string
+	confirmIndex int
+}
+
+func newTUIModel(ctx context.Context, path string, hosts pkg.Hosts) *tuiModel {
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "filter"
+
+	inputs := make([]textinput.Model, 3)
+	for i, placeholder := range []string{"ip|@var", "host", "# comment"} {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = fmt.Sprintf("%-8s ", placeholder)
+		inputs[i].Placeholder = placeholder
+	}
+
+	m := &tuiModel{
+		ctx:      ctx,
+		path:     path,
+		original: hosts,
+		hosts:    hosts.Clone(),
+		filter:   filter,
+		inputs:   inputs,
+		height:   20,
+	}
+	m.refresh()
+	return m
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.scroll()
+		return m, nil
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+
+		switch m.mode {
+		case tuiFilter:
+			return m.updateFilter(msg)
+		case tuiEdit:
+			return m.updateEdit(msg)
+		case tuiReview:
+			return m.updateReview(msg)
+		default:
+			return m.updateBrowse(msg)
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key != "q" {
+		m.quitting = false
+	}
+	if key != m.confirmKey {
+		m.confirmKey = ""
+	}
+	m.status, m.err = "", nil
+
+	switch key {
+	case "q":
+		if m.dirty() && !m.quitting {
+			m.quitting = true
+			m.status = "Unsaved changes, press q again to quit without saving"
+			return m, nil
+		}
+		return m, tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup":
+		m.move(-m.pageSize())
+	case "pgdown":
+		m.move(m.pageSize())
+	case "home", "g":
+		m.move(-len(m.rows))
+	case "end", "G":
+		m.move(len(m.rows))
+	case "/":
+		m.mode = tuiFilter
+		return m, m.filter.Focus()
+	case "tab":
+		m.grouping = (m.grouping + 1) % 3
+		m.refresh()
+	case " ":
+		if i, ok := m.selected(); ok {
+			entry := m.hosts.Entries()[i]
+			if !entry.Disabled && !m.confirmProtected(key, "space", i) {
+				return m, nil
+			}
+			entry.Disabled = !entry.Disabled
+			m.err = m.hosts.Set(i, entry)
+			m.refresh()
+		}
+	case "enter", "e":
+		if i, ok := m.selected(); ok {
+			return m, m.startEdit(i, m.hosts.Entries()[i])
+		}
+	case "a":
+		return m, m.startEdit(-1, pkg.Entry{})
+	case "d", "delete":
+		if i, ok := m.selected(); ok {
+			if !m.confirmProtected(key, key, i) {
+				return m, nil
+			}
+			m.hosts.RemoveAt(i)
+			m.refresh()
+		}
+	case "s":
+		if !m.dirty() {
+			m.status = "No changes to save"
+			return m, nil
+		}
+		m.mode = tuiReview
+	}
+	return m, nil
+}
+
+// confirmProtected reports whether the entry at index i may be changed
+// by key. Protected entries are only changed when key, shown as label,
+// is pressed twice in a row.
+func (m *tuiModel) confirmProtected(key, label string, i int) bool {
+	if !m.hosts.Entries()[i].Protected() || (m.confirmKey == key && m.confirmIndex == i) {
+		m.confirmKey = ""
+		return true
+	}
+	m.confirmKey, m.confirmIndex = key, i
+	m.status = fmt.Sprintf("Entry is protected, press %s again to change it", label)
+	return false
+}
+
+func (m *tuiModel) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.filter.SetValue("")
+		fallthrough
+	case tea.KeyEnter:
+		m.filter.Blur()
+		m.mode = tuiBrowse
+		m.refresh()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	m.refresh()
+	return m, cmd
+}
+
+func (m *tuiModel) startEdit(i int, entry pkg.Entry) tea.Cmd {
+	m.mode = tuiEdit
+	m.editing = i
+	m.focus = 0
+	m.err = nil
+
+	var ip string
+	if entry.Var != "" {
+		ip = "@" + entry.Var
+	} else if entry.IP.IsValid() {
+		ip = entry.IP.String()
+	}
+	m.inputs[0].SetValue(ip)
+	m.inputs[1].SetValue(entry.Host)
+	m.inputs[2].SetValue(entry.Comment)
+	return m.focusInput(0)
+}
+
+func (m *tuiModel) focusInput(n int) tea.Cmd {
+	m.focus = n
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+	}
+	return m.inputs[n].Focus()
+}
+
+func (m *tuiModel) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = tuiBrowse
+		m.err = nil
+		return m, nil
+	case tea.KeyTab, tea.KeyDown:
+		return m, m.focusInput((m.focus + 1) % len(m.inputs))
+	case tea.KeyShiftTab, tea.KeyUp:
+		return m, m.focusInput((m.focus + len(m.inputs) - 1) % len(m.inputs))
+	case tea.KeyEnter:
+		if err := m.commitEdit(); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.mode = tuiBrowse
+		m.err = nil
+		m.refresh()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+func (m *tuiModel) commitEdit() error {
+	ip, name, err := resolveIP(m.hosts, strings.TrimSpace(m.inputs[0].Value()))
+	if err != nil {
+		return err
+	}
+
+	host := strings.TrimSpace(m.inputs[1].Value())
+	if host == "" || strings.ContainsAny(host, " \t") {
+		return fmt.Errorf("host must be a single non-empty name")
+	}
+	if err := pkg.ValidateHost(host); err != nil {
+		return err
+	}
+
+	comment := strings.TrimSpace(m.inputs[2].Value())
+	if comment != "" && !strings.HasPrefix(comment, "#") {
+		comment = "# " + comment
+	}
+
+	if m.editing < 0 {
+		return m.hosts.AddEntry(pkg.Entry{IP: ip, Host: host, Comment: comment, Var: name})
+	}
+
+	entry := m.hosts.Entries()[m.editing]
+	entry.IP, entry.Var, entry.Host, entry.Comment = ip, name, host, comment
+	return m.hosts.Set(m.editing, entry)
+}
+
+func (m *tuiModel) updateReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "enter":
+		changes := pkg.Diff(m.original, m.hosts)
+		if err := writeHosts(m.ctx, m.path, m.original, m.hosts); err != nil {
+			m.err = err
+			return m, nil
+		}
+		hosts, err := pkg.ReadFile(m.path)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.original, m.hosts = hosts, hosts.Clone()
+		m.mode = tuiBrowse
+		m.status = fmt.Sprintf("Saved %d change(s) to %s", len(changes), m.path)
+		m.refresh()
+	case "n", "esc", "q":
+		m.mode = tuiBrowse
+		m.err = nil
+	}
+	return m, nil
+}
+
+func (m *tuiModel) dirty() bool {
+	return len(pkg.Diff(m.original, m.hosts)) > 0
+}
+
+// refresh rebuilds the visible rows from the filter and grouping while
+// keeping the cursor on the same entry where possible.
+func (m *tuiModel) refresh() {
+	selected, hadSelection := m.selected()
+
+	entries := m.hosts.Entries()
+	query := strings.ToLower(strings.TrimSpace(m.filter.Value()))
+	indexes := make([]int, 0, len(entries))
+	for i, e := range entries {
+		haystack := strings.ToLower(strings.Join(append(e.Names(), e.IP.String(), e.Comment, e.Section), " "))
+		if query == "" || strings.Contains(haystack, query) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	groupKey := func(e pkg.Entry) string {
+		switch m.grouping {
+		case tuiGroupSection:
+			if e.Section == "" {
+				return "(no section)"
+			}
+			return e.Section
+		case tuiGroupIP:
+			return e.IP.String()
+		default:
+			return ""
+		}
+	}
+
+	// Groups are ordered by where they first appear in the file.
+	rank := map[string]int{}
+	for _, i := range indexes {
+		key := groupKey(entries[i])
+		if _, ok := rank[key]; !ok {
+			rank[key] = len(rank)
+		}
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return rank[groupKey(entries[indexes[a]])] < rank[groupKey(entries[indexes[b]])]
+	})
+
+	m.rows = m.rows[:0]
+	var lastKey string
+	for n, i := range indexes {
+		key := groupKey(entries[i])
+		if m.grouping != tuiGroupNone && (n == 0 || key != lastKey) {
+			m.rows = append(m.rows, tuiRow{header: key, index: -1})
+		}
+		lastKey = key
+		m.rows = append(m.rows, tuiRow{index: i})
+	}
+
+	m.cursor = -1
+	for r, row := range m.rows {
+		if row.index < 0 {
+			continue
+		}
+		if m.cursor < 0 || (hadSelection && row.index == selected) {
+			m.cursor = r
+		}
+		if hadSelection && row.index == selected {
+			break
+		}
+	}
+	m.scroll()
+}
+
+func (m *tuiModel) selected() (int, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return 0, false
+	}
+	i := m.rows[m.cursor].index
+	return i, i >= 0 && i < len(m.hosts.Entries())
+}
+
+// move moves the cursor by delta entries, skipping group headers.
+func (m *tuiModel) move(delta int) {
+	if m.cursor < 0 {
+		return
+	}
+
+	step := 1
+	if delta < 0 {
+		step, delta = -1, -delta
+	}
+	for r := m.cursor + step; delta > 0 && r >= 0 && r < len(m.rows); r += step {
+		if m.rows[r].index >= 0 {
+			m.cursor = r
+			delta--
+		}
+	}
+	m.scroll()
+}
+
+func (m *tuiModel) pageSize() int {
+	// Leave room for the title, filter, status and help lines.
+	return max(m.height-5, 1)
+}
+
+func (m *tuiModel) scroll() {
+	page := m.pageSize()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+page {
+		m.offset = m.cursor - page + 1
+	}
+	// Keep the header of the first visible group on screen.
+	if m.offset > 0 && m.offset == m.cursor && m.rows[m.offset-1].index < 0 {
+		m.offset--
+	}
+	m.offset = max(min(m.offset, len(m.rows)-page), 0)
+}
+
+func (m *tuiModel) View() string {
+	b := strings.Builder{}
+	b.WriteString(tuiTitleStyle.Render("whosts "+m.path) + fmt.Sprintf("  group: %s\n", m.grouping))
+
+	switch m.mode {
+	case tuiEdit:
+		b.WriteString(m.viewEdit())
+	case tuiReview:
+		b.WriteString(m.viewReview())
+	default:
+		b.WriteString(m.viewBrowse())
+	}
+
+	if m.err != nil {
+		b.WriteString(tuiErrorStyle.Render(m.err.Error()) + "\n")
+	} else if m.status != "" {
+		b.WriteString(m.status + "\n")
+	}
+	return b.String()
+}
+
+func (m *tuiModel) viewBrowse() string {
+	b := strings.Builder{}
+	if m.mode == tuiFilter || m.filter.Value() != "" {
+		b.WriteString(m.filter.View() + "\n")
+	} else {
+		b.WriteString("\n")
+	}
+
+	entries := m.hosts.Entries()
+	end := min(m.offset+m.pageSize(), len(m.rows))
+	for r := m.offset; r < end; r++ {
+		row := m.rows[r]
+		if row.index < 0 {
+			b.WriteString(tuiHeaderStyle.Render(row.header) + "\n")
+			continue
+		}
+
+		e := entries[row.index]
+		state := "[x]"
+		if e.Disabled {
+			state = "[ ]"
+		}
+		line := fmt.Sprintf("%s %-39s %-40s %s", state, e.IP, strings.Join(e.Names(), " "), e.Comment)
+		switch {
+		case r == m.cursor:
+			line = tuiSelectedStyle.Render(line)
+		case e.Disabled:
+			line = tuiDisabledStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(m.rows) == 0 {
+		b.WriteString(tuiHelpStyle.Render("No entries") + "\n")
+	}
+
+	b.WriteString(tuiHelpStyle.Render("space toggle • enter edit • a add • d delete • / filter • tab group • s save • q quit") + "\n")
+	return b.String()
+}
+
+func (m *tuiModel) viewEdit() string {
+	b := strings.Builder{}
+	if m.editing < 0 {
+		b.WriteString("\nAdd entry\n")
+	} else {
+		b.WriteString("\nEdit entry\n")
+	}
+	for _, input := range m.inputs {
+		b.WriteString(input.View() + "\n")
+	}
+	b.WriteString(tuiHelpStyle.Render("tab next field • enter confirm • esc cancel") + "\n")
+	return b.String()
+}
+
+func (m *tuiModel) viewReview() string {
+	b := strings.Builder{}
+	b.WriteString("\nPending changes\n")
+	for _, c := range pkg.Diff(m.original, m.hosts) {
+		switch c.Kind {
+		case pkg.Added:
+			b.WriteString(tuiAddedStyle.Render(c.String()) + "\n")
+		case pkg.Removed:
+			b.WriteString(tuiRemovedStyle.Render(c.String()) + "\n")
+		default:
+			b.WriteString(tuiChangedStyle.Render(c.String()) + "\n")
+		}
+	}
+	b.WriteString(tuiHelpStyle.Render(fmt.Sprintf("y write to %s • n back", m.path)) + "\n")
+	return b.String()
+}
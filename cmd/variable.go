@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+// resolveIP parses s as either an IP address or a reference to a
+// variable such as @docker, returning the address and variable name.
+func resolveIP(hosts pkg.Hosts, s string) (netip.Addr, string, error) {
+	if !strings.HasPrefix(s, "@") {
+		ip, err := parseIP(s)
+		return ip, "", err
+	}
+	return hosts.ResolveAddr(s)
+}
+
+// bindEntry points entry at the address of the variable it is bound to.
+func bindEntry(hosts pkg.Hosts, entry *pkg.Entry) error {
+	if entry.Var == "" {
+		return nil
+	}
+	ip, ok := hosts.Variable(entry.Var)
+	if !ok {
+		return fmt.Errorf("@%s: %w", entry.Var, pkg.ErrUndefinedVariable)
+	}
+	entry.IP = ip
+	return nil
+}
+
+func newVarCommand() *cobra.Command {
+	var bind bool
+	cmd := &cobra.Command{
+		Use:   "var [@name ip]",
+		Short: "List variables or define one",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 && len(args) != 2 {
+				return fmt.Errorf("accepts 0 or 2 positional args: [@name ip]")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				for _, v := range hosts.Variables() {
+					fmt.Printf("@%s = %s\n", v.Name, v.IP)
+				}
+				return nil
+			}
+
+			name := strings.TrimPrefix(args[0], "@")
+			ip, err := parseIP(args[1])
+			if err != nil {
+				return err
+			}
+
+			updated := hosts.Clone()
+			if err := updated.SetVariable(name, ip); err != nil {
+				return err
+			}
+			if bind {
+				entries := updated.Entries()
+				for i, e := range entries {
+					if e.Var == "" && e.IP.Unmap() == ip.Unmap() {
+						e.Var = name
+						if err := updated.Set(i, e); err != nil {
+							return err
+						}
+					}
+				}
+			}
+
+			if err := writeHosts(cmd.Context(), hostsFile, hosts, updated); err != nil {
+				return err
+			}
+
+			fmt.Printf("@%s = %s\n", name, ip)
+			for _, c := range pkg.Diff(hosts, updated) {
+				fmt.Println(c)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&bind, "bind", false, "Bind entries already pointing at the address to the variable")
+
+	return cmd
+}
+
+func newRetargetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retarget @name ip",
+		Short: "Point a variable and every entry bound to it at a new address",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+				return fmt.Errorf("accepts 2 positional args: @name ip")
+			}
+			if !strings.HasPrefix(args[0], "@") {
+				return fmt.Errorf("variable must be referenced as @name")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			name := strings.TrimPrefix(args[0], "@")
+			if _, ok := hosts.Variable(name); !ok {
+				return fmt.Errorf("@%s: %w", name, pkg.ErrUndefinedVariable)
+			}
+			ip, err := parseIP(args[1])
+			if err != nil {
+				return err
+			}
+
+			updated := hosts.Clone()
+			if err := updated.SetVariable(name, ip); err != nil {
+				return err
+			}
+			if err := writeHosts(cmd.Context(), hostsFile, hosts, updated); err != nil {
+				return err
+			}
+
+			changes := pkg.Diff(hosts, updated)
+			for _, c := range changes {
+				fmt.Println(c)
+			}
+			fmt.Printf("Retargeted @%s to %s, %d entries updated\n", name, ip, len(changes))
+			return nil
+		},
+	}
+	return cmd
+}
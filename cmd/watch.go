@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tifye/whosts/pkg"
+)
+
+type watchOptions struct {
+	poll     time.Duration
+	jsonLine bool
+	exec     string
+	history  bool
+}
+
+type watchEvent struct {
+	Time    time.Time    `json:"time"`
+	File    string       `json:"file"`
+	Changes []pkg.Change `json:"changes"`
+}
+
+func newWatchCommand() *cobra.Command {
+	opts := &watchOptions{}
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch the hosts file and print changes made to its entries",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hostsFile, hosts, err := readHosts(cmd)
+			if err != nil {
+				return err
+			}
+
+			if opts.history {
+				if _, err := openHistory(); err != nil {
+					return err
+				}
+			}
+
+			prev := hosts
+			return watchFile(cmd.Context(), hostsFile, opts.poll, func() {
+				now := time.Now()
+				next, err := pkg.ReadFile(hostsFile)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s %s\n", now.Format(time.RFC3339), err)
+					return
+				}
+
+				changes := pkg.Diff(prev, next)
+				prev = next
+				if len(changes) == 0 {
+					return
+				}
+
+				event := watchEvent{Time: now, File: hostsFile, Changes: changes}
+				printWatchEvent(event, opts.jsonLine)
+
+				if opts.history {
+					if err := recordExternalChange(cmd.Context(), hostsFile); err != nil {
+						fmt.Fprintf(os.Stderr, "%s history: %s\n", now.Format(time.RFC3339), err)
+					}
+				}
+
+				if opts.exec != "" {
+					if err := runWatchHook(cmd, opts.exec, event); err != nil {
+						fmt.Fprintf(os.Stderr, "%s hook: %s\n", now.Format(time.RFC3339), err)
+					}
+				}
+			})
+		},
+	}
+
+	cmd.Flags().DurationVar(&opts.poll, "poll", 0, "Poll the file at this interval instead of using file system notifications")
+	cmd.Flags().BoolVar(&opts.jsonLine, "json", false, "Print each change as a JSON line")
+	cmd.Flags().BoolVar(&opts.history, "history", false, "Commit each change to the history kept by history init")
+	cmd.Flags().StringVar(&opts.exec, "exec", "", "Command to run on each change, receiving the changes as JSON on stdin")
+
+	return cmd
+}
+
+func printWatchEvent(event watchEvent, jsonLine bool) {
+	for _, c := range event.Changes {
+		if !jsonLine {
+			fmt.Printf("%s %s\n", event.Time.Format(time.RFC3339), c)
+			continue
+		}
+
+		b, err := json.Marshal(struct {
+			Time   time.Time  `json:"time"`
+			Change pkg.Change `json:"change"`
+		}{event.Time, c})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "marshal change: %s\n", err)
+			continue
+		}
+		fmt.Println(string(b))
+	}
+}
+
+func runWatchHook(cmd *cobra.Command, command string, event watchEvent) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal changes: %s", err)
+	}
+
+	hook := shellCommand(cmd.Context(), command)
+	hook.Stdin = bytes.NewReader(b)
+	hook.Stdout = os.Stdout
+	hook.Stderr = os.Stderr
+	hook.Env = append(os.Environ(), "WHOSTS_FILE="+event.File)
+	return hook.Run()
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Editors and tools often touch a file several times while saving it,
+// so events arriving within this window are reported as one change.
+const watchDebounce = 100 * time.Millisecond
+
+// watchFile calls onChange each time the file at path is modified until
+// ctx is done. When poll is non-zero the file is polled at that interval
+// instead of relying on file system notifications.
+func watchFile(ctx context.Context, path string, poll time.Duration, onChange func()) error {
+	if poll > 0 {
+		return pollFile(ctx, path, poll, onChange)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %s", err)
+	}
+	defer watcher.Close()
+
+	// Watch the directory rather than the file itself since atomic
+	// writes replace the file, which would end a watch on it.
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("watch %s: %s", filepath.Dir(path), err)
+	}
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != path || event.Has(fsnotify.Chmod) {
+				continue
+			}
+			debounce.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("watch %s: %s", path, err)
+		case <-debounce.C:
+			onChange()
+		}
+	}
+}
+
+func pollFile(ctx context.Context, path string, interval time.Duration, onChange func()) error {
+	stat := func() (time.Time, int64) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, -1
+		}
+		return info.ModTime(), info.Size()
+	}
+
+	modTime, size := stat()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			m, s := stat()
+			if m.Equal(modTime) && s == size {
+				continue
+			}
+			modTime, size = m, s
+			onChange()
+		}
+	}
+}
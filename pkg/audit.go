@@ -0,0 +1,126 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AuditRecord describes a single write to a hosts file.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// User is the OS user that made the change.
+	User string `json:"user"`
+	// Command is the command line of the process that made the change.
+	Command []string `json:"command"`
+	File    string   `json:"file"`
+	Changes []Change `json:"changes"`
+}
+
+// AppendAudit appends record as a line of JSON to the audit log at path,
+// creating the log if it does not exist.
+func AppendAudit(path string, record AuditRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal audit record: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create audit log dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+
+	// A single write keeps concurrent appends from interleaving.
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+// ReadAudit reads the records of an audit log, oldest first.
+func ReadAudit(r io.Reader) ([]AuditRecord, error) {
+	records := make([]AuditRecord, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	ln := 0
+	for scanner.Scan() {
+		ln += 1
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, lineParseErr(ln, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	return records, nil
+}
+
+// AuditFilter selects audit records. Zero fields match every record.
+type AuditFilter struct {
+	// Host matches records that changed an entry naming the host.
+	Host string
+	// IP matches records that changed an entry with the address.
+	IP    netip.Addr
+	User  string
+	Since time.Time
+	Until time.Time
+}
+
+// Match reports whether record matches all of the set fields of f.
+func (f AuditFilter) Match(record AuditRecord) bool {
+	if f.User != "" && !strings.EqualFold(f.User, record.User) {
+		return false
+	}
+	if !f.Since.IsZero() && record.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && record.Time.After(f.Until) {
+		return false
+	}
+	if f.Host == "" && !f.IP.IsValid() {
+		return true
+	}
+	for _, c := range record.Changes {
+		for _, e := range []Entry{c.Old, c.New} {
+			if f.matchEntry(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f AuditFilter) matchEntry(e Entry) bool {
+	if !e.IP.IsValid() {
+		return false
+	}
+	if f.IP.IsValid() && f.IP.Unmap() != e.IP.Unmap() {
+		return false
+	}
+	if f.Host == "" {
+		return true
+	}
+	name := canonicalName(f.Host)
+	for _, n := range e.Names() {
+		if canonicalName(n) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "whosts", "audit.log")
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	api := Entry{IP: netip.MustParseAddr("10.0.0.1"), Host: "api.dev", Aliases: []string{"api"}}
+	moved := api
+	moved.IP = netip.MustParseAddr("10.0.0.2")
+	records := []AuditRecord{
+		{Time: start, User: "alice", Command: []string{"whosts", "add"}, File: "/etc/hosts", Changes: []Change{{Kind: Added, New: api}}},
+		{Time: start.Add(time.Hour), User: "bob", Command: []string{"whosts", "retarget"}, File: "/etc/hosts", Changes: []Change{{Kind: Changed, Old: api, New: moved}}},
+		{Time: start.Add(2 * time.Hour), User: "alice", Command: []string{"whosts", "remove"}, File: "/etc/hosts", Changes: []Change{{Kind: Removed, Old: moved}}},
+	}
+	for _, record := range records {
+		require.NoError(t, AppendAudit(path, record))
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	read, err := ReadAudit(f)
+	require.NoError(t, err)
+	require.Len(t, read, 3)
+	assert.Equal(t, "bob", read[1].User)
+	assert.Equal(t, "~ 10.0.0.1 api.dev api -> 10.0.0.2 api.dev api", read[1].Changes[0].String())
+	assert.Equal(t, "- 10.0.0.2 api.dev api", read[2].Changes[0].String())
+
+	count := func(filter AuditFilter) int {
+		n := 0
+		for _, record := range read {
+			if filter.Match(record) {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(t, 3, count(AuditFilter{}))
+	assert.Equal(t, 2, count(AuditFilter{User: "Alice"}))
+	assert.Equal(t, 3, count(AuditFilter{Host: "API"}))
+	assert.Equal(t, 0, count(AuditFilter{Host: "web.dev"}))
+	assert.Equal(t, 2, count(AuditFilter{IP: netip.MustParseAddr("10.0.0.2")}))
+	assert.Equal(t, 2, count(AuditFilter{Since: start.Add(time.Hour)}))
+	assert.Equal(t, 1, count(AuditFilter{Since: start.Add(30 * time.Minute), Until: start.Add(90 * time.Minute)}))
+}
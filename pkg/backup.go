@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+type Backup struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+
+	path string
+}
+
+func (b Backup) Path() string {
+	return b.path
+}
+
+// CreateBackup copies the hosts file at path into dir, keeping at most
+// keep backups of it, and returns the new backup. It does nothing and
+// returns a zero Backup if the file does not exist.
+func CreateBackup(path, dir string, keep int) (Backup, error) {
+	dir, err := fileBackupDir(path, dir)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	src, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Backup{}, nil
+	}
+	if err != nil {
+		return Backup{}, fmt.Errorf("open file: %w", err)
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Backup{}, fmt.Errorf("create backup dir: %w", err)
+	}
+
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s.%s.bak", filepath.Base(path), now.Format(backupTimeFormat))
+	dst, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return Backup{}, fmt.Errorf("create backup: %w", err)
+	}
+	size, err := io.Copy(dst, src)
+	if err != nil {
+		dst.Close()
+		return Backup{}, fmt.Errorf("write backup: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return Backup{}, fmt.Errorf("close backup: %w", err)
+	}
+
+	backups, err := listBackups(path, dir)
+	if err != nil {
+		return Backup{}, err
+	}
+	for len(backups) > keep {
+		if err := os.Remove(backups[len(backups)-1].path); err != nil {
+			return Backup{}, fmt.Errorf("remove old backup: %w", err)
+		}
+		backups = backups[:len(backups)-1]
+	}
+	return Backup{Name: name, Time: now, Size: size, path: filepath.Join(dir, name)}, nil
+}
+
+// ListBackups returns the backups of the hosts file at path found in
+// dir, newest first.
+func ListBackups(path, dir string) ([]Backup, error) {
+	dir, err := fileBackupDir(path, dir)
+	if err != nil {
+		return nil, err
+	}
+	return listBackups(path, dir)
+}
+
+// fileBackupDir returns the directory within dir that the backups of the
+// hosts file at path are kept in. It is named after the absolute path of
+// the file, like its history snapshot, so that files with the same name
+// in different directories keep their backups apart.
+func fileBackupDir(path, dir string) (string, error) {
+	name, err := historyName(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(name)), nil
+}
+
+func listBackups(path, dir string) ([]Backup, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read backup dir: %w", err)
+	}
+
+	prefix := filepath.Base(path) + "."
+	backups := make([]Backup, 0)
+	for _, d := range dirEntries {
+		name := d.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok || d.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, ".bak")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{
+			Name: name,
+			Time: t,
+			Size: info.Size(),
+			path: filepath.Join(dir, name),
+		})
+	}
+
+	slices.SortFunc(backups, func(a, b Backup) int {
+		return b.Time.Compare(a.Time)
+	})
+	return backups, nil
+}
@@ -0,0 +1,312 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var ErrNoMatch = errors.New("no entries match")
+
+// BatchOps are the operations a batch file can contain.
+var BatchOps = []string{"add", "remove", "set", "enable", "disable"}
+
+// BatchOp is a single operation of a batch file.
+type BatchOp struct {
+	Op string `json:"op"`
+	// IP and Host are the entry to add or set, or filters for the other
+	// operations together with Comment, Section and Tags.
+	IP      string            `json:"ip,omitempty"`
+	Host    string            `json:"host,omitempty"`
+	Aliases []string          `json:"aliases,omitempty"`
+	Comment string            `json:"comment,omitempty"`
+	Section string            `json:"section,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+
+	// Line is the line of the batch file the operation was read from.
+	Line int `json:"-"`
+}
+
+// ParseBatch reads the operations of a batch file. Each line is either
+// an operation written like the command of the same name, such as
+// "add 10.0.0.1 api.dev --tag owner=web" or "remove --ip 10.0.0.1", or a
+// JSON object such as {"op":"disable","host":"api.dev"}. Blank lines and
+// lines starting with # are skipped.
+func ParseBatch(r io.Reader) ([]BatchOp, error) {
+	ops := make([]BatchOp, 0)
+	scanner := bufio.NewScanner(r)
+	ln := 0
+	for scanner.Scan() {
+		ln += 1
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var op BatchOp
+		var err error
+		if strings.HasPrefix(line, "{") {
+			op, err = parseBatchJSON(line)
+		} else {
+			op, err = parseBatchLine(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", ln, err)
+		}
+		op.Line = ln
+		if err := op.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", ln, err)
+		}
+		ops = append(ops, op)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read batch file: %w", err)
+	}
+	return ops, nil
+}
+
+func parseBatchJSON(line string) (BatchOp, error) {
+	var op BatchOp
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&op); err != nil {
+		return BatchOp{}, err
+	}
+	if dec.More() {
+		return BatchOp{}, fmt.Errorf("unexpected data after the JSON object")
+	}
+	return op, nil
+}
+
+func parseBatchLine(line string) (BatchOp, error) {
+	fields, err := splitFields(line)
+	if err != nil {
+		return BatchOp{}, err
+	}
+
+	op := BatchOp{Op: fields[0]}
+	if !slices.Contains(BatchOps, op.Op) {
+		return BatchOp{}, errUnknownBatchOp(op.Op)
+	}
+	flags := pflag.NewFlagSet(op.Op, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&op.Comment, "comment", "", "")
+	flags.StringToStringVar(&op.Tags, "tag", nil, "")
+	if op.Op != "add" && op.Op != "set" {
+		flags.StringVar(&op.IP, "ip", "", "")
+		flags.StringVar(&op.Host, "host", "", "")
+		flags.StringVar(&op.Section, "section", "", "")
+	}
+	if err := flags.Parse(fields[1:]); err != nil {
+		return BatchOp{}, fmt.Errorf("%s: %w", op.Op, err)
+	}
+
+	args := flags.Args()
+	switch op.Op {
+	case "add", "set":
+		if len(args) < 2 {
+			return BatchOp{}, fmt.Errorf("%s: expected <ip|@variable> <host>", op.Op)
+		}
+		op.IP, op.Host, op.Aliases = args[0], args[1], args[2:]
+	case "enable", "disable":
+		// The host may be passed as an argument instead of with --host.
+		if len(args) == 1 && op.Host == "" {
+			op.Host, args = args[0], nil
+		}
+		fallthrough
+	default:
+		if len(args) > 0 {
+			return BatchOp{}, fmt.Errorf("%s: unexpected arguments %s", op.Op, strings.Join(args, " "))
+		}
+	}
+	return op, nil
+}
+
+func (op BatchOp) validate() error {
+	if !slices.Contains(BatchOps, op.Op) {
+		return errUnknownBatchOp(op.Op)
+	}
+	if err := op.validateFields(); err != nil {
+		return fmt.Errorf("%s: %w", op.Op, err)
+	}
+	return nil
+}
+
+func (op BatchOp) validateFields() error {
+	switch op.Op {
+	case "add", "set":
+		if op.IP == "" || op.Host == "" {
+			return fmt.Errorf("ip and host are required")
+		}
+		if !strings.HasPrefix(op.IP, "@") {
+			if _, err := netip.ParseAddr(op.IP); err != nil {
+				return invalidIPErr([]byte(op.IP))
+			}
+		}
+		for _, name := range append([]string{op.Host}, op.Aliases...) {
+			if err := ValidateHost(name); err != nil {
+				return err
+			}
+		}
+		if op.Op == "set" && (len(op.Aliases) > 0 || op.Comment != "" || len(op.Tags) > 0) {
+			return fmt.Errorf("only takes an ip and a host")
+		}
+	case "remove", "enable", "disable":
+		if op.IP == "" && op.Host == "" && op.Comment == "" && op.Section == "" && len(op.Tags) == 0 {
+			return fmt.Errorf("needs at least one of ip, host, comment, section or tag")
+		}
+		if op.IP != "" {
+			if _, err := netip.ParseAddr(op.IP); err != nil {
+				return invalidIPErr([]byte(op.IP))
+			}
+		}
+	}
+	return nil
+}
+
+func errUnknownBatchOp(op string) error {
+	return fmt.Errorf("unknown operation %q, expected %s", op, strings.Join(BatchOps, ", "))
+}
+
+func (op BatchOp) filters() []FilterOption {
+	filters := make([]FilterOption, 0)
+	if op.IP != "" {
+		// The IP was validated when the batch file was parsed.
+		filters = append(filters, WithIPs(netip.MustParseAddr(op.IP)))
+	}
+	if op.Host != "" {
+		filters = append(filters, WithHosts(op.Host))
+	}
+	if op.Comment != "" {
+		filters = append(filters, WithComments(op.Comment))
+	}
+	if op.Section != "" {
+		filters = append(filters, WithSections(op.Section))
+	}
+	if len(op.Tags) > 0 {
+		filters = append(filters, WithTags(op.Tags))
+	}
+	return filters
+}
+
+// ApplyBatch applies ops to a copy of hosts and returns it, along with
+// the protected entries that were skipped. Unless force is set,
+// protected entries are not removed, disabled or pointed elsewhere. If
+// an operation fails its error is returned and none of the operations
+// are applied.
+func ApplyBatch(hosts Hosts, ops []BatchOp, force bool) (Hosts, []Entry, error) {
+	updated := hosts.Clone()
+	var skipped []Entry
+	for _, op := range ops {
+		opSkipped, err := applyBatchOp(&updated, op, force)
+		if err != nil {
+			return Hosts{}, nil, fmt.Errorf("line %d: %s: %w", op.Line, op.Op, err)
+		}
+		skipped = append(skipped, opSkipped...)
+	}
+	return updated, skipped, nil
+}
+
+func applyBatchOp(hosts *Hosts, op BatchOp, force bool) ([]Entry, error) {
+	switch op.Op {
+	case "add":
+		ip, name, err := hosts.ResolveAddr(op.IP)
+		if err != nil {
+			return nil, err
+		}
+		entry := Entry{IP: ip, Host: op.Host, Aliases: op.Aliases, Var: name}
+		if op.Comment != "" {
+			entry.Comment = "# " + strings.TrimSpace(strings.TrimPrefix(op.Comment, "#"))
+		}
+		if len(op.Tags) > 0 {
+			if err := entry.SetTags(op.Tags); err != nil {
+				return nil, err
+			}
+		}
+		return nil, hosts.AddEntry(entry)
+	case "set":
+		ip, name, err := hosts.ResolveAddr(op.IP)
+		if err != nil {
+			return nil, err
+		}
+		return hosts.PointHost(op.Host, ip, name, force)
+	case "remove":
+		var removed, skipped []Entry
+		if force {
+			removed = hosts.Remove(false, op.filters()...)
+		} else {
+			removed, skipped = hosts.RemoveUnprotected(false, op.filters()...)
+		}
+		if len(removed) == 0 && len(skipped) == 0 {
+			return nil, ErrNoMatch
+		}
+		return skipped, nil
+	default:
+		return hosts.setDisabled(op.Op == "disable", force, op.filters()...)
+	}
+}
+
+// setDisabled disables or enables the entries matching filters. Unless
+// force is set, protected entries are not disabled and are returned.
+func (h *Hosts) setDisabled(disabled, force bool, filters ...FilterOption) ([]Entry, error) {
+	matched := h.Find(append(filters, WithDisabled())...)
+	if len(matched) == 0 {
+		return nil, ErrNoMatch
+	}
+
+	var skipped []Entry
+	for _, i := range matched {
+		entry := h.entries[i]
+		if disabled && !entry.Disabled && entry.Protected() && !force {
+			skipped = append(skipped, entry)
+			continue
+		}
+		entry.Disabled = disabled
+		if err := h.Set(i, entry); err != nil {
+			return nil, err
+		}
+	}
+	return skipped, nil
+}
+
+// splitFields splits line into fields separated by whitespace, keeping
+// text in single or double quotes together.
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	var quote rune
+	inField := false
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			field.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inField = r, true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
@@ -0,0 +1,213 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []BatchOp
+		err      string
+	}{
+		{
+			name: "commands",
+			input: `# comment
+
+add 10.0.0.1 api.dev api --comment "the api" --tag owner=web
+set @docker db.dev
+remove --ip 10.0.0.2 --section dev
+disable api.dev
+enable --tag owner=web
+`,
+			expected: []BatchOp{
+				{Op: "add", IP: "10.0.0.1", Host: "api.dev", Aliases: []string{"api"}, Comment: "the api", Tags: map[string]string{"owner": "web"}, Line: 3},
+				{Op: "set", IP: "@docker", Host: "db.dev", Aliases: []string{}, Line: 4},
+				{Op: "remove", IP: "10.0.0.2", Section: "dev", Line: 5},
+				{Op: "disable", Host: "api.dev", Line: 6},
+				{Op: "enable", Tags: map[string]string{"owner": "web"}, Line: 7},
+			},
+		},
+		{
+			name:  "json",
+			input: `{"op":"add","ip":"10.0.0.1","host":"api.dev","tags":{"owner":"web"}}`,
+			expected: []BatchOp{
+				{Op: "add", IP: "10.0.0.1", Host: "api.dev", Tags: map[string]string{"owner": "web"}, Line: 1},
+			},
+		},
+		{
+			name:  "json with unknown field",
+			input: `{"op":"remove","hostname":"api.dev"}`,
+			err:   `line 1: json: unknown field "hostname"`,
+		},
+		{
+			name:  "json with trailing data",
+			input: `{"op":"remove","host":"api.dev"} {"op":"remove"}`,
+			err:   "line 1: unexpected data after the JSON object",
+		},
+		{
+			name:  "unknown operation",
+			input: "\nrename api.dev web.dev",
+			err:   `line 2: unknown operation "rename", expected add, remove, set, enable, disable`,
+		},
+		{
+			name:  "missing host",
+			input: "add 10.0.0.1",
+			err:   "line 1: add: expected <ip|@variable> <host>",
+		},
+		{
+			name:  "invalid host",
+			input: "add 10.0.0.1 bad_name",
+			err:   `line 1: add: invalid host name "bad_name": idna: disallowed rune U+005F`,
+		},
+		{
+			name:  "remove without filters",
+			input: `{"op":"remove"}`,
+			err:   "line 1: remove: needs at least one of ip, host, comment, section or tag",
+		},
+		{
+			name:  "set with comment",
+			input: "set 10.0.0.1 api.dev --comment note",
+			err:   "line 1: set: only takes an ip and a host",
+		},
+		{
+			name:  "unknown flag",
+			input: "add 10.0.0.1 api.dev --section dev",
+			err:   "line 1: add: unknown flag: --section",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops, err := ParseBatch(strings.NewReader(test.input))
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, ops)
+		})
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+		err      bool
+	}{
+		{input: "add 10.0.0.1  api.dev", expected: []string{"add", "10.0.0.1", "api.dev"}},
+		{input: "\tremove --comment \"two words\"", expected: []string{"remove", "--comment", "two words"}},
+		{input: "add --comment='it''s' x", expected: []string{"add", "--comment=its", "x"}},
+		{input: `add --comment "say 'hi'"`, expected: []string{"add", "--comment", "say 'hi'"}},
+		{input: `remove --comment ""`, expected: []string{"remove", "--comment", ""}},
+		{input: `remove --comment "open`, err: true},
+	}
+
+	for _, test := range tests {
+		fields, err := splitFields(test.input)
+		if test.err {
+			assert.Error(t, err, test.input)
+			continue
+		}
+		require.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, fields, test.input)
+	}
+}
+
+func TestApplyBatch(t *testing.T) {
+	input := `# @docker = 172.17.0.1
+127.0.0.1 localhost
+10.0.0.1 api.dev # [owner=web]
+10.0.0.2 db.dev
+`
+	parse := func(t *testing.T, batch string) []BatchOp {
+		ops, err := ParseBatch(strings.NewReader(batch))
+		require.NoError(t, err)
+		return ops
+	}
+
+	tests := []struct {
+		name     string
+		batch    string
+		force    bool
+		expected string
+		skipped  []string
+		err      string
+	}{
+		{
+			name: "all operations",
+			batch: `add @docker web.dev --tag owner=web
+set 10.0.0.3 db.dev
+remove --host api.dev
+disable --tag owner=web
+`,
+			expected: `# @docker = 172.17.0.1
+127.0.0.1 localhost
+10.0.0.3 db.dev
+# 172.17.0.1 web.dev # [owner=web] # @docker
+`,
+		},
+		{
+			name:    "protected entries are skipped",
+			batch:   "remove --ip 127.0.0.1\ndisable localhost\nset 10.0.0.9 localhost\n",
+			skipped: []string{"127.0.0.1 localhost", "127.0.0.1 localhost", "127.0.0.1 localhost"},
+			expected: `# @docker = 172.17.0.1
+127.0.0.1 localhost
+10.0.0.1 api.dev # [owner=web]
+10.0.0.2 db.dev
+`,
+		},
+		{
+			name:  "protected entries are changed with force",
+			batch: "disable localhost\n",
+			force: true,
+			expected: `# @docker = 172.17.0.1
+# 127.0.0.1 localhost
+10.0.0.1 api.dev # [owner=web]
+10.0.0.2 db.dev
+`,
+		},
+		{
+			name:  "failing operation applies nothing",
+			batch: "remove --host db.dev\nadd 10.0.0.4 new.dev\nremove --host missing.dev\n",
+			err:   "line 3: remove: no entries match",
+		},
+		{
+			name:  "undefined variable applies nothing",
+			batch: "remove --host db.dev\nset @missing db.dev\n",
+			err:   "line 2: set: @missing: variable is not defined",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hosts, err := ParseEntries(strings.NewReader(input))
+			require.NoError(t, err)
+
+			updated, skipped, err := ApplyBatch(hosts, parse(t, test.batch), test.force)
+			// The hosts passed in are never changed.
+			assert.Equal(t, input, hosts.String())
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, updated.String())
+
+			actual := make([]string, 0, len(skipped))
+			for _, e := range skipped {
+				actual = append(actual, e.String())
+			}
+			if test.skipped == nil {
+				test.skipped = []string{}
+			}
+			assert.Equal(t, test.skipped, actual)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DedupeKey is what entries must have in common to be duplicates.
+type DedupeKey string
+
+const (
+	// ByHost makes entries with the same names duplicates.
+	ByHost DedupeKey = "host"
+	// ByHostIP makes entries with the same names and IP duplicates.
+	ByHostIP DedupeKey = "host+ip"
+	// ByHostFamily makes entries with the same names and IP version
+	// duplicates, so a name keeps one IPv4 and one IPv6 address.
+	ByHostFamily DedupeKey = "host+family"
+)
+
+// KeepStrategy is which of a group of duplicates is kept.
+type KeepStrategy string
+
+const (
+	KeepFirst KeepStrategy = "first"
+	KeepLast  KeepStrategy = "last"
+	// MergeComments keeps the first entry, merging the comments and
+	// tags of the others into it.
+	MergeComments KeepStrategy = "merge-comments"
+)
+
+// ParseDedupeKey returns the dedupe key with the given name.
+func ParseDedupeKey(s string) (DedupeKey, error) {
+	for _, key := range []DedupeKey{ByHost, ByHostIP, ByHostFamily} {
+		if strings.EqualFold(s, string(key)) {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("unknown dedupe key %q, expected host, host+ip or host+family", s)
+}
+
+// ParseKeepStrategy returns the keep strategy with the given name. The
+// names may be prefixed with "keep-".
+func ParseKeepStrategy(s string) (KeepStrategy, error) {
+	s = strings.TrimPrefix(strings.ToLower(s), "keep-")
+	for _, keep := range []KeepStrategy{KeepFirst, KeepLast, MergeComments} {
+		if s == string(keep) {
+			return keep, nil
+		}
+	}
+	return "", fmt.Errorf("unknown keep strategy %q, expected first, last or merge-comments", s)
+}
+
+// Dedupe removes the enabled entries that are duplicates by the given
+// key, keeping one entry of each group of duplicates as chosen by keep.
+// Entries are only duplicates if they have all of their names in common,
+// compared case insensitively and in any order, so "10.0.0.1 api.dev api"
+// and "10.0.0.1 api.dev" are both kept.
+//
+// Only the entries of the named section are deduped, or the entries
+// outside of any section if it is empty, since the entries of sections
+// managed by apply or sync would be added back by the next run.
+//
+// Unless force is set, protected duplicates are kept, and comments are
+// not merged into a protected entry, which leaves its duplicates as they
+// are. It returns the changes this made and the protected entries that
+// were left as they are.
+func (h *Hosts) Dedupe(by DedupeKey, keep KeepStrategy, section string, force bool) ([]Change, []Entry) {
+	groups := map[string][]int{}
+	for i, e := range h.entries {
+		if e.Disabled || e.Section != section {
+			continue
+		}
+		key := dedupeKey(e, by)
+		groups[key] = append(groups[key], i)
+	}
+
+	removed := make([]bool, len(h.entries))
+	changes := make([]Change, 0)
+	var skipped []Entry
+	for i, e := range h.entries {
+		group := groups[dedupeKey(e, by)]
+		if e.Disabled || e.Section != section || len(group) < 2 || group[0] != i {
+			continue
+		}
+
+		kept := group[0]
+		if keep == KeepLast {
+			kept = group[len(group)-1]
+		}
+		// Protected duplicates are kept as if they were not duplicates.
+		merging := []int{kept}
+		for _, j := range group {
+			if j == kept {
+				continue
+			}
+			if h.entries[j].Protected() && !force {
+				skipped = append(skipped, h.entries[j])
+				continue
+			}
+			merging = append(merging, j)
+		}
+		if keep == MergeComments {
+			merged := mergeComments(h.entries, merging)
+			if merged.String() != h.entries[kept].String() {
+				// The duplicates stay too, rather than losing their
+				// comments.
+				if h.entries[kept].Protected() && !force {
+					skipped = append(skipped, h.entries[kept])
+					continue
+				}
+				changes = append(changes, Change{Kind: Changed, Old: h.entries[kept], New: merged})
+				h.entries[kept] = merged
+			}
+		}
+		for _, j := range merging[1:] {
+			removed[j] = true
+			changes = append(changes, Change{Kind: Removed, Old: h.entries[j]})
+		}
+	}
+
+	entries := make([]Entry, 0, len(h.entries))
+	for i, e := range h.entries {
+		if !removed[i] {
+			entries = append(entries, e)
+		}
+	}
+	h.entries = entries
+	return changes, skipped
+}
+
+func dedupeKey(e Entry, by DedupeKey) string {
+	names := make([]string, 0, len(e.Names()))
+	for _, name := range e.Names() {
+		names = append(names, canonicalName(name))
+	}
+	slices.Sort(names)
+	key := strings.Join(names, " ")
+
+	ip := e.IP.Unmap()
+	switch by {
+	case ByHostIP:
+		key += "|" + ip.String()
+	case ByHostFamily:
+		if ip.Is4() {
+			key += "|4"
+		} else {
+			key += "|6"
+		}
+	}
+	return key
+}
+
+// mergeComments returns the first entry of group with the distinct
+// comments of all of the entries joined and their tags merged, the first
+// value of a tag winning.
+func mergeComments(entries []Entry, group []int) Entry {
+	merged := entries[group[0]]
+	var comments []string
+	var tags [][2]string
+	seen := map[string]bool{}
+	for _, i := range group {
+		text, meta := splitMeta(entries[i].Comment)
+		comment := strings.TrimSpace(strings.TrimLeft(text, "#"))
+		if comment != "" && !seen[comment] {
+			seen[comment] = true
+			comments = append(comments, comment)
+		}
+		for _, kv := range meta {
+			if !slices.ContainsFunc(tags, func(tag [2]string) bool { return tag[0] == kv[0] }) {
+				tags = append(tags, kv)
+			}
+		}
+	}
+
+	var text string
+	if len(comments) > 0 {
+		text = "# " + strings.Join(comments, "; ")
+	}
+	merged.Comment = joinMeta(text, tags)
+	return merged
+}
@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupe(t *testing.T) {
+	input := "10.0.0.1 api.dev # first\n" +
+		"10.0.0.1 API.dev. # second [owner=web]\n" +
+		"10.0.0.2 api.dev\n" +
+		"::1 api.dev # v6\n" +
+		"# 10.0.0.1 api.dev\n" +
+		"10.0.0.9 other.dev\n" +
+		"# BEGIN docker\n" +
+		"10.0.0.1 api.dev\n" +
+		"10.0.0.1 api.dev\n" +
+		"# END docker\n"
+	docker := "# BEGIN docker\n10.0.0.1 api.dev\n10.0.0.1 api.dev\n# END docker\n"
+
+	tests := []struct {
+		by       DedupeKey
+		keep     KeepStrategy
+		expected string
+		changes  int
+	}{
+		{
+			ByHostIP, KeepFirst,
+			"10.0.0.1 api.dev # first\n10.0.0.2 api.dev\n::1 api.dev # v6\n# 10.0.0.1 api.dev\n10.0.0.9 other.dev\n" + docker,
+			1,
+		},
+		{
+			ByHostIP, KeepLast,
+			"10.0.0.1 API.dev. # second [owner=web]\n10.0.0.2 api.dev\n::1 api.dev # v6\n# 10.0.0.1 api.dev\n10.0.0.9 other.dev\n" + docker,
+			1,
+		},
+		{
+			ByHostFamily, KeepFirst,
+			"10.0.0.1 api.dev # first\n::1 api.dev # v6\n# 10.0.0.1 api.dev\n10.0.0.9 other.dev\n" + docker,
+			2,
+		},
+		{
+			ByHost, MergeComments,
+			"10.0.0.1 api.dev # first; second; v6 [owner=web]\n# 10.0.0.1 api.dev\n10.0.0.9 other.dev\n" + docker,
+			4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.by)+" "+string(tt.keep), func(t *testing.T) {
+			hosts, err := ParseEntries(strings.NewReader(input))
+			require.NoError(t, err)
+
+			changes, _ := hosts.Dedupe(tt.by, tt.keep, "", true)
+			assert.Len(t, changes, tt.changes)
+			assert.Equal(t, tt.expected, hosts.String())
+		})
+	}
+}
+
+func TestDedupeSection(t *testing.T) {
+	input := "10.0.0.1 api.dev\n# BEGIN docker\n10.0.0.1 api.dev\n10.0.0.1 api.dev\n# END docker\n"
+	hosts, err := ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+
+	changes, _ := hosts.Dedupe(ByHostIP, KeepFirst, "docker", false)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "10.0.0.1 api.dev\n# BEGIN docker\n10.0.0.1 api.dev\n# END docker\n", hosts.String())
+}
+
+func TestDedupeAllNames(t *testing.T) {
+	// Entries are only duplicates if all of their names match.
+	input := "10.0.0.1 api.dev api\n10.0.0.1 api.dev # other\n10.0.0.1 API api.dev\n"
+	hosts, err := ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+
+	changes, _ := hosts.Dedupe(ByHostIP, KeepFirst, "", false)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "10.0.0.1 API api.dev", changes[0].Old.String())
+	assert.Equal(t, "10.0.0.1 api.dev api\n10.0.0.1 api.dev # other\n", hosts.String())
+}
+
+func TestDedupeProtected(t *testing.T) {
+	input := "127.0.0.1 localhost\n::1 localhost\n10.0.0.1 api.dev # [protected=true]\n10.0.0.1 api.dev # copy\n"
+
+	hosts, err := ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+	changes, skipped := hosts.Dedupe(ByHost, MergeComments, "", false)
+	assert.Empty(t, changes)
+	require.Len(t, skipped, 2)
+	assert.Equal(t, "::1 localhost", skipped[0].String())
+	assert.Equal(t, "10.0.0.1 api.dev # [protected=true]", skipped[1].String())
+	assert.Equal(t, input, hosts.String())
+
+	hosts, err = ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+	changes, skipped = hosts.Dedupe(ByHost, KeepLast, "", false)
+	assert.Len(t, changes, 0)
+	assert.Len(t, skipped, 2)
+
+	hosts, err = ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+	changes, skipped = hosts.Dedupe(ByHost, KeepFirst, "", true)
+	assert.Len(t, changes, 2)
+	assert.Empty(t, skipped)
+	assert.Equal(t, "127.0.0.1 localhost\n10.0.0.1 api.dev # [protected=true]\n", hosts.String())
+}
+
+func TestParseDedupeOptions(t *testing.T) {
+	key, err := ParseDedupeKey("HOST+IP")
+	require.NoError(t, err)
+	assert.Equal(t, ByHostIP, key)
+	_, err = ParseDedupeKey("ip")
+	assert.Error(t, err)
+
+	keep, err := ParseKeepStrategy("keep-last")
+	require.NoError(t, err)
+	assert.Equal(t, KeepLast, keep)
+	keep, err = ParseKeepStrategy("merge-comments")
+	require.NoError(t, err)
+	assert.Equal(t, MergeComments, keep)
+	_, err = ParseKeepStrategy("newest")
+	assert.Error(t, err)
+}
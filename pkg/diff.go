@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota
+	Removed
+	Changed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Change describes how a single entry differs between two versions of
+// a hosts file. Old is unset for added entries and New is unset for
+// removed entries.
+type Change struct {
+	Kind ChangeKind
+	Old  Entry
+	New  Entry
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return "+ " + c.New.String()
+	case Removed:
+		return "- " + c.Old.String()
+	default:
+		return fmt.Sprintf("~ %s -> %s", c.Old, c.New)
+	}
+}
+
+func (c Change) MarshalJSON() ([]byte, error) {
+	change := struct {
+		Kind ChangeKind `json:"kind"`
+		Old  *Entry     `json:"old,omitempty"`
+		New  *Entry     `json:"new,omitempty"`
+	}{Kind: c.Kind}
+	if c.Kind != Added {
+		change.Old = &c.Old
+	}
+	if c.Kind != Removed {
+		change.New = &c.New
+	}
+	return json.Marshal(change)
+}
+
+// Diff returns the entries that were added, removed or changed going
+// from old to new. Identical entries are matched regardless of their
+// position in the file, and the remaining entries are considered
+// changed when they share a host name or the same line.
+func Diff(old, new Hosts) []Change {
+	oldEntries, newEntries := old.Entries(), new.Entries()
+	oldMatched := make([]bool, len(oldEntries))
+	newMatched := make([]bool, len(newEntries))
+
+	unmatched := map[string][]int{}
+	for i, e := range oldEntries {
+		key := e.String()
+		unmatched[key] = append(unmatched[key], i)
+	}
+	for i, e := range newEntries {
+		key := e.String()
+		if idxs := unmatched[key]; len(idxs) > 0 {
+			oldMatched[idxs[0]] = true
+			newMatched[i] = true
+			unmatched[key] = idxs[1:]
+		}
+	}
+
+	changes := make([]Change, 0)
+	for i, e := range newEntries {
+		if newMatched[i] {
+			continue
+		}
+
+		change := Change{Kind: Added, New: e}
+		if j := findUnmatched(oldEntries, oldMatched, func(o Entry) bool { return o.Host == e.Host }); j >= 0 {
+			oldMatched[j] = true
+			change = Change{Kind: Changed, Old: oldEntries[j], New: e}
+		} else if j := findUnmatched(oldEntries, oldMatched, func(o Entry) bool { return e.line > 0 && o.line == e.line }); j >= 0 {
+			oldMatched[j] = true
+			change = Change{Kind: Changed, Old: oldEntries[j], New: e}
+		}
+		changes = append(changes, change)
+	}
+
+	for i, e := range oldEntries {
+		if !oldMatched[i] {
+			changes = append(changes, Change{Kind: Removed, Old: e})
+		}
+	}
+
+	return changes
+}
+
+func findUnmatched(entries []Entry, matched []bool, match func(Entry) bool) int {
+	for i, e := range entries {
+		if !matched[i] && match(e) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (k *ChangeKind) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "added":
+		*k = Added
+	case "removed":
+		*k = Removed
+	case "changed":
+		*k = Changed
+	default:
+		return fmt.Errorf("unknown change kind %q", b)
+	}
+	return nil
+}
+
+func (c *Change) UnmarshalJSON(b []byte) error {
+	var change struct {
+		Kind ChangeKind `json:"kind"`
+		Old  Entry      `json:"old"`
+		New  Entry      `json:"new"`
+	}
+	if err := json.Unmarshal(b, &change); err != nil {
+		return err
+	}
+	*c = Change{Kind: change.Kind, Old: change.Old, New: change.New}
+	return nil
+}
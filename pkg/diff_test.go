@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	local := Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"}
+	api := Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "api.dev"}
+	web := Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 2}), Host: "web.dev"}
+	apiMoved := Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 9}), Host: "api.dev"}
+	localDisabled := Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Disabled: true}
+
+	tests := []struct {
+		name     string
+		old      []Entry
+		new      []Entry
+		expected []Change
+	}{
+		{
+			name:     "no changes",
+			old:      []Entry{local, api},
+			new:      []Entry{api, local},
+			expected: []Change{},
+		},
+		{
+			name:     "added",
+			old:      []Entry{local},
+			new:      []Entry{local, web},
+			expected: []Change{{Kind: Added, New: web}},
+		},
+		{
+			name:     "removed",
+			old:      []Entry{local, api},
+			new:      []Entry{local},
+			expected: []Change{{Kind: Removed, Old: api}},
+		},
+		{
+			name:     "changed IP",
+			old:      []Entry{local, api},
+			new:      []Entry{local, apiMoved},
+			expected: []Change{{Kind: Changed, Old: api, New: apiMoved}},
+		},
+		{
+			name:     "disabled",
+			old:      []Entry{local},
+			new:      []Entry{localDisabled},
+			expected: []Change{{Kind: Changed, Old: local, New: localDisabled}},
+		},
+		{
+			name:     "duplicate removed",
+			old:      []Entry{local, local},
+			new:      []Entry{local},
+			expected: []Change{{Kind: Removed, Old: local}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			old, err := NewHosts(test.old)
+			require.NoError(t, err)
+			updated, err := NewHosts(test.new)
+			require.NoError(t, err)
+			changes := Diff(old, updated)
+			assert.Equal(t, test.expected, changes)
+		})
+	}
+}
+
+func TestChangeMarshalJSON(t *testing.T) {
+	entry := Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "api.dev", Comment: "# API"}
+
+	b, err := json.Marshal(Change{Kind: Added, New: entry})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"kind":"added","new":{"ip":"10.0.0.1","host":"api.dev","comment":"# API"}}`, string(b))
+
+	b, err = json.Marshal(Change{Kind: Removed, Old: entry})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"kind":"removed","old":{"ip":"10.0.0.1","host":"api.dev","comment":"# API"}}`, string(b))
+}
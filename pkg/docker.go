@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// DockerContainer is the subset of a container as returned by the
+// Docker Engine API container inspect endpoint, or by `docker inspect`.
+type DockerContainer struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	State *struct {
+		Running bool `json:"Running"`
+	} `json:"State"`
+	NetworkSettings struct {
+		Networks map[string]DockerNetwork `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// DockerNetwork is the settings of a container on one of its networks.
+type DockerNetwork struct {
+	IPAddress         string   `json:"IPAddress"`
+	GlobalIPv6Address string   `json:"GlobalIPv6Address"`
+	Aliases           []string `json:"Aliases"`
+}
+
+// DockerEntries returns an entry for every address of every running
+// container, named after the container and its network aliases and
+// tagged with the network. Names that are not valid host names are
+// skipped and returned as errors.
+func DockerEntries(containers []DockerContainer) ([]Entry, []error) {
+	entries := make([]Entry, 0)
+	var errs []error
+	for _, c := range containers {
+		if c.State != nil && !c.State.Running {
+			continue
+		}
+		name := strings.TrimPrefix(c.Name, "/")
+
+		networks := make([]string, 0, len(c.NetworkSettings.Networks))
+		for network := range c.NetworkSettings.Networks {
+			networks = append(networks, network)
+		}
+		slices.Sort(networks)
+
+		for _, network := range networks {
+			settings := c.NetworkSettings.Networks[network]
+			names := make([]string, 0, len(settings.Aliases)+1)
+			for i, alias := range append([]string{name}, settings.Aliases...) {
+				// Docker aliases containers by their short ID on
+				// user defined networks.
+				if alias == "" || slices.Contains(names, alias) || (i > 0 && strings.HasPrefix(c.ID, alias)) {
+					continue
+				}
+				if err := ValidateHost(alias); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				names = append(names, alias)
+			}
+			if len(names) == 0 {
+				continue
+			}
+
+			for _, addr := range []string{settings.IPAddress, settings.GlobalIPv6Address} {
+				ip, err := netip.ParseAddr(addr)
+				if err != nil {
+					continue
+				}
+				entry := Entry{IP: ip, Host: names[0], Aliases: names[1:]}
+				if err := entry.SetTag("network", network); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, errs
+}
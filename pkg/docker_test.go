@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		errs     int
+	}{
+		{
+			name: "networks and aliases",
+			input: `[{"Id": "0123456789ab", "Name": "/web", "State": {"Running": true},
+  "NetworkSettings": {"Networks": {
+    "shop": {"IPAddress": "172.18.0.2", "GlobalIPv6Address": "fd00::2", "Aliases": ["web", "0123456789ab", "shop-web"]},
+    "bridge": {"IPAddress": "172.17.0.2"}
+  }}}]`,
+			expected: []string{
+				"172.17.0.2 web # [network=bridge]",
+				"172.18.0.2 web shop-web # [network=shop]",
+				"fd00::2 web shop-web # [network=shop]",
+			},
+		},
+		{
+			name: "stopped containers skipped",
+			input: `[{"Name": "/db", "State": {"Running": false},
+  "NetworkSettings": {"Networks": {"bridge": {"IPAddress": "172.17.0.3"}}}}]`,
+			expected: []string{},
+		},
+		{
+			name:     "networks without addresses skipped",
+			input:    `[{"Name": "/db", "NetworkSettings": {"Networks": {"none": {}, "bridge": {"IPAddress": "172.17.0.3"}}}}]`,
+			expected: []string{"172.17.0.3 db # [network=bridge]"},
+		},
+		{
+			name: "invalid names skipped",
+			input: `[{"Name": "/my_app", "NetworkSettings": {"Networks": {
+    "bridge": {"IPAddress": "172.17.0.4", "Aliases": ["app", "bad_alias"]}
+  }}}]`,
+			expected: []string{"172.17.0.4 app # [network=bridge]"},
+			errs:     2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var containers []DockerContainer
+			require.NoError(t, json.Unmarshal([]byte(test.input), &containers))
+
+			entries, errs := DockerEntries(containers)
+			actual := make([]string, 0, len(entries))
+			for _, e := range entries {
+				actual = append(actual, e.String())
+			}
+			assert.Equal(t, test.expected, actual)
+			assert.Len(t, errs, test.errs)
+			for _, err := range errs {
+				assert.ErrorIs(t, err, ErrInvalidHost)
+			}
+		})
+	}
+}
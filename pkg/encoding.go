@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding is the character encoding of a hosts file.
+type Encoding string
+
+const (
+	UTF8    Encoding = "utf-8"
+	UTF8BOM Encoding = "utf-8-bom"
+	UTF16LE Encoding = "utf-16le"
+	UTF16BE Encoding = "utf-16be"
+)
+
+// Encodings lists the supported encodings.
+var Encodings = []Encoding{UTF8, UTF8BOM, UTF16LE, UTF16BE}
+
+// EOL is the line ending of a hosts file.
+type EOL string
+
+const (
+	LF   EOL = "lf"
+	CRLF EOL = "crlf"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// ParseEncoding returns the encoding with the given name.
+func ParseEncoding(s string) (Encoding, error) {
+	for _, enc := range Encodings {
+		if strings.EqualFold(s, string(enc)) {
+			return enc, nil
+		}
+	}
+	return "", fmt.Errorf("unknown encoding %q", s)
+}
+
+// ParseEOL returns the line ending with the given name.
+func ParseEOL(s string) (EOL, error) {
+	switch strings.ToLower(s) {
+	case string(LF):
+		return LF, nil
+	case string(CRLF):
+		return CRLF, nil
+	}
+	return "", fmt.Errorf("unknown line ending %q, expected lf or crlf", s)
+}
+
+// Encoding returns the encoding the hosts are written in. It is the
+// encoding of the parsed file, or UTF-8 for new hosts.
+func (h Hosts) Encoding() Encoding {
+	if h.encoding == "" {
+		return UTF8
+	}
+	return h.encoding
+}
+
+// SetEncoding sets the encoding the hosts are written in.
+func (h *Hosts) SetEncoding(enc Encoding) {
+	h.encoding = enc
+}
+
+// EOL returns the line ending the hosts are written with. It is the
+// line ending of the parsed file, or LF for new hosts.
+func (h Hosts) EOL() EOL {
+	if h.eol == "" {
+		return LF
+	}
+	return h.eol
+}
+
+// SetEOL sets the line ending the hosts are written with.
+func (h *Hosts) SetEOL(eol EOL) {
+	h.eol = eol
+}
+
+// decodeReader detects the encoding of r from its byte order mark and
+// returns a reader of its contents as UTF-8 without the mark.
+func decodeReader(r io.Reader) (io.Reader, Encoding, error) {
+	br := bufio.NewReader(r)
+	prefix, err := br.Peek(3)
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+
+	switch {
+	case bytes.HasPrefix(prefix, utf8BOM):
+		_, _ = br.Discard(len(utf8BOM))
+		return br, UTF8BOM, nil
+	case bytes.HasPrefix(prefix, utf16LEBOM):
+		dec := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()
+		return transform.NewReader(br, dec), UTF16LE, nil
+	case bytes.HasPrefix(prefix, utf16BEBOM):
+		dec := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()
+		return transform.NewReader(br, dec), UTF16BE, nil
+	}
+	return br, UTF8, nil
+}
+
+// encode returns text in enc with the lines ending in eol.
+func encode(text string, enc Encoding, eol EOL) ([]byte, error) {
+	if eol == CRLF {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+
+	switch enc {
+	case UTF8BOM:
+		return append(bytes.Clone(utf8BOM), text...), nil
+	case UTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(text))
+	case UTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(text))
+	}
+	return []byte(text), nil
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestEncodingRoundTrip(t *testing.T) {
+	text := "# Copyright (c) Microsoft Corp.\r\n127.0.0.1 localhost # café\r\n"
+	utf16le, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(text))
+	require.NoError(t, err)
+	utf16be, err := unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(text))
+	require.NoError(t, err)
+
+	tt := []struct {
+		name     string
+		input    []byte
+		encoding Encoding
+		eol      EOL
+	}{
+		{name: "utf-8 lf", input: []byte("127.0.0.1 localhost\n"), encoding: UTF8, eol: LF},
+		{name: "utf-8 crlf", input: []byte(text), encoding: UTF8, eol: CRLF},
+		{name: "utf-8 bom", input: append([]byte{0xEF, 0xBB, 0xBF}, text...), encoding: UTF8BOM, eol: CRLF},
+		{name: "utf-16le", input: utf16le, encoding: UTF16LE, eol: CRLF},
+		{name: "utf-16be", input: utf16be, encoding: UTF16BE, eol: CRLF},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			hosts, err := ParseEntries(bytes.NewReader(tc.input))
+			require.NoError(t, err)
+			assert.Equal(t, tc.encoding, hosts.Encoding())
+			assert.Equal(t, tc.eol, hosts.EOL())
+			require.Len(t, hosts.Entries(), 1)
+			assert.Equal(t, "localhost", hosts.Entries()[0].Host)
+
+			var buf bytes.Buffer
+			_, err = hosts.WriteTo(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, tc.input, buf.Bytes())
+		})
+	}
+}
+
+func TestConvertEncoding(t *testing.T) {
+	hosts, err := ParseEntries(bytes.NewReader([]byte("127.0.0.1 localhost\n")))
+	require.NoError(t, err)
+
+	hosts.SetEncoding(UTF16LE)
+	hosts.SetEOL(CRLF)
+	var buf bytes.Buffer
+	_, err = hosts.WriteTo(&buf)
+	require.NoError(t, err)
+
+	reparsed, err := ParseEntries(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, UTF16LE, reparsed.Encoding())
+	assert.Equal(t, CRLF, reparsed.EOL())
+	assert.Equal(t, hosts.String(), reparsed.String())
+
+	_, err = ParseEncoding("latin1")
+	assert.Error(t, err)
+	_, err = ParseEOL("cr")
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"strings"
+	"time"
+)
+
+const expiresKey = "expires"
+
+// Expires returns when the entry expires. The expiry is stored in the
+// entry's comment as metadata, e.g. "# debugging [expires=2024-01-02T15:04:05Z]".
+func (e Entry) Expires() (time.Time, bool) {
+	_, meta := splitMeta(e.Comment)
+	for _, kv := range meta {
+		if kv[0] != expiresKey {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, kv[1])
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// SetExpires stores in the entry's comment that it expires at t.
+func (e *Entry) SetExpires(t time.Time) {
+	text, meta := splitMeta(e.Comment)
+	value := t.UTC().Format(time.RFC3339)
+
+	var found bool
+	for i, kv := range meta {
+		if kv[0] == expiresKey {
+			meta[i][1] = value
+			found = true
+		}
+	}
+	if !found {
+		meta = append(meta, [2]string{expiresKey, value})
+	}
+	e.Comment = joinMeta(text, meta)
+}
+
+// Expired reports whether the entry has an expiry at or before now.
+func (e Entry) Expired(now time.Time) bool {
+	t, ok := e.Expires()
+	return ok && !t.After(now)
+}
+
+// RemoveExpired removes the entries that expired at or before now and
+// returns them.
+func (h *Hosts) RemoveExpired(now time.Time) []Entry {
+	kept := make([]Entry, 0, len(h.entries))
+	removed := make([]Entry, 0)
+	for _, e := range h.entries {
+		if e.Expired(now) {
+			removed = append(removed, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	h.entries = kept
+	return removed
+}
+
+// DisableExpired disables the enabled entries that expired at or before
+// now and returns them.
+func (h *Hosts) DisableExpired(now time.Time) []Entry {
+	disabled := make([]Entry, 0)
+	for i, e := range h.entries {
+		if !e.Disabled && e.Expired(now) {
+			h.entries[i].Disabled = true
+			disabled = append(disabled, h.entries[i])
+		}
+	}
+	return disabled
+}
+
+// splitMeta splits a comment into its free text and the key=value
+// pairs of a trailing metadata block in square brackets.
+func splitMeta(comment string) (string, [][2]string) {
+	trimmed := strings.TrimSpace(comment)
+	if !strings.HasSuffix(trimmed, "]") {
+		return comment, nil
+	}
+	start := strings.LastIndexByte(trimmed, '[')
+	if start < 0 {
+		return comment, nil
+	}
+
+	fields := strings.Fields(trimmed[start+1 : len(trimmed)-1])
+	if len(fields) == 0 {
+		return comment, nil
+	}
+	meta := make([][2]string, 0, len(fields))
+	for _, f := range fields {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || k == "" {
+			return comment, nil
+		}
+		meta = append(meta, [2]string{k, v})
+	}
+
+	text := strings.TrimSpace(trimmed[:start])
+	if text == "#" {
+		text = ""
+	}
+	return text, meta
+}
+
+// joinMeta is the inverse of splitMeta.
+func joinMeta(text string, meta [][2]string) string {
+	if len(meta) == 0 {
+		return text
+	}
+
+	pairs := make([]string, 0, len(meta))
+	for _, kv := range meta {
+		pairs = append(pairs, kv[0]+"="+kv[1])
+	}
+	block := "[" + strings.Join(pairs, " ") + "]"
+	if text == "" {
+		return "# " + block
+	}
+	return text + " " + block
+}
@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryExpires(t *testing.T) {
+	expiry := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	entry := Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "debug.local"}
+	_, ok := entry.Expires()
+	assert.False(t, ok)
+
+	entry.SetExpires(expiry)
+	assert.Equal(t, "# [expires=2024-01-02T15:04:05Z]", entry.Comment)
+
+	entry.Comment = "# debugging [owner=me expires=2024-01-02T15:04:05Z]"
+	got, ok := entry.Expires()
+	require.True(t, ok)
+	assert.Equal(t, expiry, got)
+
+	entry.SetExpires(expiry.Add(time.Hour))
+	assert.Equal(t, "# debugging [owner=me expires=2024-01-02T16:04:05Z]", entry.Comment)
+
+	assert.False(t, entry.Expired(expiry))
+	assert.True(t, entry.Expired(expiry.Add(time.Hour)))
+
+	entry.Comment = "# not [metadata]"
+	_, ok = entry.Expires()
+	assert.False(t, ok)
+}
+
+func TestRemoveExpired(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader(`127.0.0.1 localhost
+127.0.0.1 old.local # [expires=2024-01-01T00:00:00Z]
+127.0.0.1 new.local # [expires=2024-01-03T00:00:00Z]
+# 127.0.0.1 disabled.local # [expires=2024-01-01T00:00:00Z]
+`))
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	disabling := hosts.Clone()
+	disabled := disabling.DisableExpired(now)
+	require.Len(t, disabled, 1)
+	assert.Equal(t, "old.local", disabled[0].Host)
+	assert.True(t, disabling.Entries()[1].Disabled)
+
+	removed := hosts.RemoveExpired(now)
+	require.Len(t, removed, 2)
+	assert.Equal(t, "old.local", removed[0].Host)
+	assert.Equal(t, "disabled.local", removed[1].Host)
+	assert.Len(t, hosts.Entries(), 2)
+}
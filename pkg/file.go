@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ReadFile parses the hosts file at path.
+func ReadFile(path string) (Hosts, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Hosts{}, fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	return ParseEntries(file)
+}
+
+// ReadFileLenient parses the hosts file at path like ParseEntriesLenient.
+func ReadFileLenient(path string) (Hosts, []*ParseError, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Hosts{}, nil, fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	return ParseEntriesLenient(file)
+}
+
+// WriteFile replaces the hosts file at path with h. The contents are
+// first written to a temporary file next to it which is then renamed
+// over the original, so readers never observe a partially written file.
+// The file keeps its mode and, where the OS has them, its owner.
+//
+// Files that cannot be replaced are written in place instead, such as
+// the /etc/hosts that Docker bind mounts into containers, on which the
+// rename fails with EBUSY or EXDEV, or files whose owner cannot be kept
+// because the process may not chown to it.
+func WriteFile(path string, h Hosts) error {
+	perm := fs.FileMode(0644)
+	info, err := os.Stat(path)
+	if err == nil {
+		perm = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("stat file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := h.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("chmod temp file: %w", err)
+	}
+	if info != nil {
+		if err := chownLike(tmp.Name(), info); err != nil {
+			return writeInPlace(path, h)
+		}
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EXDEV) {
+		return writeInPlace(path, h)
+	}
+	if err != nil {
+		return fmt.Errorf("replace file: %w", err)
+	}
+	return nil
+}
+
+// writeInPlace truncates the file at path and writes h to it, keeping
+// the file itself, with its mode and owner. Readers may observe it
+// partially written.
+func writeInPlace(path string, h Hosts) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	if _, err := h.WriteTo(file); err != nil {
+		file.Close()
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("sync file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+	return nil
+}
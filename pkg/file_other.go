@@ -0,0 +1,10 @@
+//go:build !unix
+
+package pkg
+
+import "io/fs"
+
+// chownLike does nothing on systems without Unix file owners.
+func chownLike(path string, info fs.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	require.NoError(t, os.WriteFile(path, []byte("# header\n127.0.0.1\tlocalhost\n"), 0600))
+
+	hosts, err := ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, hosts.AddEntry(Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "api.dev"}))
+	require.NoError(t, WriteFile(path, hosts))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# header\n127.0.0.1\tlocalhost\n10.0.0.1 api.dev\n", string(b))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestWriteInPlace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	require.NoError(t, os.WriteFile(path, []byte("127.0.0.1 localhost\n10.0.0.1 old.dev\n"), 0600))
+	// A hard link sees the write only if the file itself is written to,
+	// like a bind mount would.
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Link(path, link))
+
+	hosts, err := ParseEntries(strings.NewReader("127.0.0.1 localhost\n"))
+	require.NoError(t, err)
+	require.NoError(t, writeInPlace(path, hosts))
+
+	b, err := os.ReadFile(link)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1 localhost\n", string(b))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+
+	unlock, err := Lock(context.Background(), path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = Lock(ctx, path)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, unlock())
+	unlock, err = Lock(context.Background(), path)
+	require.NoError(t, err)
+	assert.NoError(t, unlock())
+}
+
+func TestBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	backupDir := filepath.Join(dir, "backups")
+
+	_, err := CreateBackup(path, backupDir, 2)
+	require.NoError(t, err, "missing file is not backed up")
+	backups, err := ListBackups(path, backupDir)
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+
+	var latest Backup
+	for _, content := range []string{"1", "2", "3"} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		latest, err = CreateBackup(path, backupDir, 2)
+		require.NoError(t, err)
+	}
+
+	backups, err = ListBackups(path, backupDir)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, latest.Path(), backups[0].Path())
+	b, err := os.ReadFile(backups[0].Path())
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(b))
+}
+
+func TestBackupsSameName(t *testing.T) {
+	dir := t.TempDir()
+	backupDir := filepath.Join(dir, "backups")
+	first := filepath.Join(dir, "a", "hosts")
+	second := filepath.Join(dir, "b", "hosts")
+	for _, path := range []string{first, second} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(path), 0644))
+	}
+
+	for range 2 {
+		_, err := CreateBackup(first, backupDir, 2)
+		require.NoError(t, err)
+	}
+	_, err := CreateBackup(second, backupDir, 1)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		path  string
+		count int
+	}{{first, 2}, {second, 1}} {
+		backups, err := ListBackups(tt.path, backupDir)
+		require.NoError(t, err)
+		require.Len(t, backups, tt.count, "pruning one file keeps the backups of the other")
+		for _, backup := range backups {
+			b, err := os.ReadFile(backup.Path())
+			require.NoError(t, err)
+			assert.Equal(t, tt.path, string(b))
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrFlushSkipped is returned by Flusher.Detect when the cache it flushes
+// is not active on this machine.
+var ErrFlushSkipped = errors.New("skipped")
+
+// Flusher flushes a DNS resolver cache so changes to the hosts file take
+// effect immediately.
+type Flusher interface {
+	// Name names the resolver cache.
+	Name() string
+	// Detect returns nil if the cache is active on this machine, or an
+	// error wrapping ErrFlushSkipped describing why it is not.
+	Detect(ctx context.Context) error
+	// Flush flushes the cache.
+	Flush(ctx context.Context) error
+}
+
+// FlushResult is the outcome of flushing one resolver cache.
+type FlushResult struct {
+	Name string
+	// Err is nil if the cache was flushed. It wraps ErrFlushSkipped if
+	// the cache was not active.
+	Err error
+}
+
+func (r FlushResult) String() string {
+	switch {
+	case r.Err == nil:
+		return fmt.Sprintf("flushed %s", r.Name)
+	case errors.Is(r.Err, ErrFlushSkipped):
+		reason := strings.TrimPrefix(r.Err.Error(), ErrFlushSkipped.Error()+": ")
+		return fmt.Sprintf("skipped %s: %s", r.Name, reason)
+	default:
+		return fmt.Sprintf("failed to flush %s: %s", r.Name, r.Err)
+	}
+}
+
+// FlushDNS flushes each of the flushers that detects its cache as active.
+func FlushDNS(ctx context.Context, flushers []Flusher) []FlushResult {
+	results := make([]FlushResult, 0, len(flushers))
+	for _, f := range flushers {
+		err := f.Detect(ctx)
+		if err == nil {
+			err = f.Flush(ctx)
+		}
+		results = append(results, FlushResult{Name: f.Name(), Err: err})
+	}
+	return results
+}
+
+var (
+	systemdResolvedFlusher = commandFlusher{
+		name:   "systemd-resolved",
+		detect: [][]string{{"systemctl", "is-active", "--quiet", "systemd-resolved"}},
+		flush:  [][]string{{"resolvectl", "flush-caches"}},
+	}
+	nscdFlusher = commandFlusher{
+		name:   "nscd",
+		detect: [][]string{{"pgrep", "-x", "nscd"}},
+		flush:  [][]string{{"nscd", "-i", "hosts"}},
+	}
+	dnsmasqFlusher = commandFlusher{
+		name:   "dnsmasq",
+		detect: [][]string{{"pgrep", "-x", "dnsmasq"}},
+		// dnsmasq clears its cache and rereads the hosts file on SIGHUP.
+		flush: [][]string{{"pkill", "-HUP", "-x", "dnsmasq"}},
+	}
+	windowsFlusher = commandFlusher{
+		name:  "Windows DNS client",
+		flush: [][]string{{"ipconfig", "/flushdns"}},
+	}
+	mDNSResponderFlusher = commandFlusher{
+		name:   "mDNSResponder",
+		detect: [][]string{{"pgrep", "-x", "mDNSResponder"}},
+		flush:  [][]string{{"dscacheutil", "-flushcache"}, {"killall", "-HUP", "mDNSResponder"}},
+	}
+)
+
+// DefaultFlushers returns flushers for the resolver caches commonly
+// found on the current OS.
+func DefaultFlushers() []Flusher {
+	switch runtime.GOOS {
+	case "windows":
+		return []Flusher{windowsFlusher}
+	case "darwin":
+		return []Flusher{mDNSResponderFlusher, dnsmasqFlusher}
+	default:
+		return []Flusher{systemdResolvedFlusher, nscdFlusher, dnsmasqFlusher}
+	}
+}
+
+// commandFlusher detects and flushes a cache by running commands.
+type commandFlusher struct {
+	name string
+	// detect are the commands that must succeed for the cache to be
+	// considered active, in addition to the flush commands existing.
+	detect [][]string
+	flush  [][]string
+}
+
+func (f commandFlusher) Name() string {
+	return f.name
+}
+
+func (f commandFlusher) Detect(ctx context.Context) error {
+	for _, args := range append(f.detect, f.flush...) {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			return fmt.Errorf("%w: %s not found", ErrFlushSkipped, args[0])
+		}
+	}
+	for _, args := range f.detect {
+		if err := exec.CommandContext(ctx, args[0], args[1:]...).Run(); err != nil {
+			return fmt.Errorf("%w: not running", ErrFlushSkipped)
+		}
+	}
+	return nil
+}
+
+func (f commandFlusher) Flush(ctx context.Context) error {
+	for _, args := range f.flush {
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(out))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return fmt.Errorf("%s: %s", strings.Join(args, " "), msg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFlusher struct {
+	name      string
+	detectErr error
+	flushErr  error
+	flushed   bool
+}
+
+func (f *fakeFlusher) Name() string { return f.name }
+
+func (f *fakeFlusher) Detect(context.Context) error { return f.detectErr }
+
+func (f *fakeFlusher) Flush(context.Context) error {
+	f.flushed = true
+	return f.flushErr
+}
+
+func TestFlushDNS(t *testing.T) {
+	active := &fakeFlusher{name: "active"}
+	inactive := &fakeFlusher{name: "inactive", detectErr: fmt.Errorf("%w: not running", ErrFlushSkipped)}
+	failing := &fakeFlusher{name: "failing", flushErr: errors.New("permission denied")}
+
+	results := FlushDNS(context.Background(), []Flusher{active, inactive, failing})
+	require.Len(t, results, 3)
+
+	assert.True(t, active.flushed)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "flushed active", results[0].String())
+
+	assert.False(t, inactive.flushed)
+	assert.ErrorIs(t, results[1].Err, ErrFlushSkipped)
+	assert.Equal(t, "skipped inactive: not running", results[1].String())
+
+	assert.True(t, failing.flushed)
+	assert.Equal(t, "failed to flush failing: permission denied", results[2].String())
+}
+
+func TestCommandFlusherMissingCommand(t *testing.T) {
+	f := commandFlusher{name: "missing", flush: [][]string{{"whosts-no-such-command"}}}
+	assert.ErrorIs(t, f.Detect(context.Background()), ErrFlushSkipped)
+}
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyChanges is wrapped by the errors of ChangeLimit.Check.
+var ErrTooManyChanges = errors.New("too many entries changed")
+
+// minGuardedChanges is how many entries must be removed or rewritten
+// before ChangeLimit.Percent applies, so small files can still be edited.
+const minGuardedChanges = 5
+
+// ChangeLimit limits how many entries of a hosts file a single write
+// may remove or rewrite. Zero fields are not checked.
+type ChangeLimit struct {
+	// Percent is the share of the entries that may be affected.
+	Percent float64
+	// Count is the number of entries that may be affected.
+	Count int
+}
+
+// Check returns an error wrapping ErrTooManyChanges if changes, made to
+// the entries of before, remove or rewrite more entries than allowed.
+func (l ChangeLimit) Check(before Hosts, changes []Change) error {
+	var removed, rewritten int
+	for _, c := range changes {
+		switch c.Kind {
+		case Removed:
+			removed++
+		case Changed:
+			rewritten++
+		}
+	}
+	affected := removed + rewritten
+	total := len(before.Entries())
+
+	var limit string
+	switch {
+	case l.Count > 0 && affected > l.Count:
+		limit = fmt.Sprintf("%d entries", l.Count)
+	case l.Percent > 0 && affected >= minGuardedChanges && float64(affected) > l.Percent/100*float64(total):
+		limit = fmt.Sprintf("%g%% of the entries", l.Percent)
+	default:
+		return nil
+	}
+
+	share := 100.0
+	if total > 0 {
+		share = 100 * float64(affected) / float64(total)
+	}
+	return fmt.Errorf("%w: the change removes %d and rewrites %d of %d entries (%.0f%%), more than the limit of %s",
+		ErrTooManyChanges, removed, rewritten, total, share, limit)
+}
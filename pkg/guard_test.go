@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeLimit(t *testing.T) {
+	entries := make([]Entry, 0, 40)
+	for i := 0; i < 40; i++ {
+		entries = append(entries, Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, byte(i)}), Host: fmt.Sprintf("host%d.dev", i)})
+	}
+	before, err := NewHosts(entries)
+	require.NoError(t, err)
+
+	changes := func(removed, changed, added int) []Change {
+		c := make([]Change, 0)
+		for i := 0; i < removed; i++ {
+			c = append(c, Change{Kind: Removed, Old: entries[i]})
+		}
+		for i := 0; i < changed; i++ {
+			c = append(c, Change{Kind: Changed, Old: entries[removed+i], New: entries[removed+i]})
+		}
+		for i := 0; i < added; i++ {
+			c = append(c, Change{Kind: Added, New: entries[i]})
+		}
+		return c
+	}
+
+	percent := ChangeLimit{Percent: 20}
+	assert.NoError(t, percent.Check(before, changes(4, 4, 30)))
+	err = percent.Check(before, changes(6, 3, 0))
+	assert.ErrorIs(t, err, ErrTooManyChanges)
+	assert.EqualError(t, err, "too many entries changed: the change removes 6 and rewrites 3 of 40 entries (22%), more than the limit of 20% of the entries")
+
+	// Small changes are allowed regardless of the share.
+	small, err := NewHosts(entries[:4])
+	require.NoError(t, err)
+	assert.NoError(t, percent.Check(small, changes(4, 0, 0)))
+
+	count := ChangeLimit{Count: 2}
+	assert.NoError(t, count.Check(before, changes(2, 0, 0)))
+	assert.ErrorIs(t, count.Check(before, changes(1, 2, 0)), ErrTooManyChanges)
+
+	assert.NoError(t, ChangeLimit{}.Check(before, changes(40, 0, 0)))
+}
@@ -0,0 +1,171 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNoHistory is returned by OpenHistory when no history has been
+// initialized in the directory.
+var ErrNoHistory = errors.New("history is not initialized")
+
+// History is a git repository holding snapshots of hosts files. It is
+// managed with the git command, which must be installed.
+type History struct {
+	dir string
+}
+
+// Revision is a snapshot of a hosts file in the history.
+type Revision struct {
+	Hash    string    `json:"hash"`
+	Time    time.Time `json:"time"`
+	Author  string    `json:"author"`
+	Message string    `json:"message"`
+}
+
+// InitHistory creates a history in dir, or opens the one already there.
+func InitHistory(ctx context.Context, dir string) (History, error) {
+	h := History{dir: dir}
+	if _, err := OpenHistory(dir); err == nil {
+		return h, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return History{}, fmt.Errorf("create history dir: %w", err)
+	}
+	if _, err := h.git(ctx, nil, "init", "--quiet"); err != nil {
+		return History{}, err
+	}
+	return h, nil
+}
+
+// OpenHistory opens the history in dir.
+func OpenHistory(dir string) (History, error) {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return History{}, ErrNoHistory
+	}
+	if err != nil {
+		return History{}, fmt.Errorf("open history: %w", err)
+	}
+	return History{dir: dir}, nil
+}
+
+func (h History) Dir() string {
+	return h.dir
+}
+
+// Commit records content as the snapshot of the hosts file at path. It
+// reports false without committing if the snapshot is unchanged.
+func (h History) Commit(ctx context.Context, path string, content []byte, author, message string) (bool, error) {
+	name, err := historyName(path)
+	if err != nil {
+		return false, err
+	}
+	file := filepath.Join(h.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return false, fmt.Errorf("create snapshot dir: %w", err)
+	}
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		return false, fmt.Errorf("write snapshot: %w", err)
+	}
+
+	if _, err := h.git(ctx, nil, "add", "--", name); err != nil {
+		return false, err
+	}
+	if _, err := h.git(ctx, nil, "diff", "--cached", "--quiet", "--", name); err == nil {
+		return false, nil
+	}
+
+	if author == "" {
+		author = "whosts"
+	}
+	_, err = h.git(ctx, []byte(message),
+		"-c", "user.name="+author,
+		"-c", "user.email="+author+"@localhost",
+		"-c", "commit.gpgsign=false",
+		"commit", "--quiet", "--file=-", "--", name,
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Log returns the revisions of the hosts file at path, newest first.
+func (h History) Log(ctx context.Context, path string) ([]Revision, error) {
+	name, err := historyName(path)
+	if err != nil {
+		return nil, err
+	}
+	// A history without commits has no revisions.
+	if _, err := h.git(ctx, nil, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return []Revision{}, nil
+	}
+
+	out, err := h.git(ctx, nil, "log", "--format=%H%x00%aI%x00%an%x00%s", "--", name)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]Revision, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse time of %s: %w", fields[0], err)
+		}
+		revisions = append(revisions, Revision{Hash: fields[0], Time: t, Author: fields[2], Message: fields[3]})
+	}
+	return revisions, nil
+}
+
+// Show returns the snapshot of the hosts file at path in revision rev,
+// which can be anything git accepts as a revision such as a hash prefix.
+func (h History) Show(ctx context.Context, rev, path string) ([]byte, error) {
+	name, err := historyName(path)
+	if err != nil {
+		return nil, err
+	}
+	return h.git(ctx, nil, "show", rev+":"+name)
+}
+
+func (h History) git(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", h.dir}, args...)...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// historyName returns the name of the snapshot of the hosts file at
+// path, which is its absolute path within the repository.
+func historyName(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("absolute path: %w", err)
+	}
+	if vol := filepath.VolumeName(abs); vol != "" {
+		abs = strings.TrimSuffix(strings.TrimPrefix(vol, `\\`), ":") + abs[len(vol):]
+	}
+	return strings.TrimPrefix(filepath.ToSlash(abs), "/"), nil
+}
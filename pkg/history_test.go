@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "history")
+	hostsFile := filepath.Join(t.TempDir(), "hosts")
+
+	_, err := OpenHistory(dir)
+	require.ErrorIs(t, err, ErrNoHistory)
+
+	h, err := InitHistory(ctx, dir)
+	require.NoError(t, err)
+
+	revisions, err := h.Log(ctx, hostsFile)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	committed, err := h.Commit(ctx, hostsFile, []byte("127.0.0.1 localhost\n"), "alice", "Initial snapshot")
+	require.NoError(t, err)
+	assert.True(t, committed)
+
+	committed, err = h.Commit(ctx, hostsFile, []byte("127.0.0.1 localhost\n"), "alice", "Unchanged")
+	require.NoError(t, err)
+	assert.False(t, committed)
+
+	committed, err = h.Commit(ctx, hostsFile, []byte("127.0.0.1 localhost\n10.0.0.1 api.dev\n"), "bob", "whosts add 10.0.0.1 api.dev\n\n+ 10.0.0.1 api.dev")
+	require.NoError(t, err)
+	assert.True(t, committed)
+
+	h, err = OpenHistory(dir)
+	require.NoError(t, err)
+	revisions, err = h.Log(ctx, hostsFile)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "bob", revisions[0].Author)
+	assert.Equal(t, "whosts add 10.0.0.1 api.dev", revisions[0].Message)
+	assert.Equal(t, "Initial snapshot", revisions[1].Message)
+
+	content, err := h.Show(ctx, revisions[1].Hash[:7], hostsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1 localhost\n", string(content))
+
+	_, err = h.Show(ctx, "nope", hostsFile)
+	assert.Error(t, err)
+}
@@ -1,46 +1,237 @@
 package pkg
 
 import (
+	"fmt"
 	"io"
-	"net"
+	"maps"
+	"net/netip"
+	"slices"
+	"sort"
 	"strings"
 )
 
 type Hosts struct {
 	entries []Entry
+	// lines holds the lines of the parsed file which are not entries,
+	// such as comments and blank lines, so they can be written back.
+	lines []textLine
+	// raw holds the original text of parsed entries by line number so
+	// unchanged entries are written back as they were.
+	raw map[int]textLine
+
+	// encoding and eol are how the hosts are written, as detected when
+	// parsing a file.
+	encoding Encoding
+	eol      EOL
+}
+
+type textLine struct {
+	line       int
+	text       string
+	section    string
+	sectionEnd bool
+	variable   Variable
+	// entry is the string form of the entry parsed from text, if any.
+	entry string
 }
 
-func NewHosts(entries []Entry) Hosts {
-	return Hosts{entries: entries}
+// NewHosts returns hosts made up of entries, which must have addresses
+// and valid host names.
+func NewHosts(entries []Entry) (Hosts, error) {
+	for _, e := range entries {
+		if err := e.Validate(); err != nil {
+			return Hosts{}, err
+		}
+	}
+	return Hosts{entries: entries}, nil
 }
 
-func (h *Hosts) AddEntry(entry Entry) {
+// AddEntry adds entry to the end of its section, or of the file if it
+// has none. The entry must have an address and valid host names.
+func (h *Hosts) AddEntry(entry Entry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
 	h.entries = append(h.entries, entry)
+	return nil
 }
 
 func (h Hosts) Entries() []Entry {
 	return h.entries
 }
 
+// Set replaces the entry at index i. The entry keeps its position in
+// the file unless it is moved to another section. Host names the entry
+// did not have before must be valid, so entries parsed with invalid
+// names can still be changed otherwise.
+func (h *Hosts) Set(i int, entry Entry) error {
+	if err := entry.validateChanged(h.entries[i]); err != nil {
+		return err
+	}
+	if entry.Section == h.entries[i].Section {
+		entry.line = h.entries[i].line
+	} else {
+		entry.line = 0
+	}
+	h.entries[i] = entry
+	return nil
+}
+
+// PointHost points the enabled entries of host at ip, bound to the
+// variable if set, or adds an entry for it if it has none. Unless force
+// is set, protected entries are left as they are and returned.
+func (h *Hosts) PointHost(host string, ip netip.Addr, variable string, force bool) ([]Entry, error) {
+	var found bool
+	var skipped []Entry
+	for _, i := range h.Find(WithHosts(host)) {
+		entry := h.entries[i]
+		if entry.Disabled {
+			continue
+		}
+		found = true
+		if entry.Protected() && !force && (entry.IP != ip || entry.Var != variable) {
+			skipped = append(skipped, entry)
+			continue
+		}
+		entry.IP, entry.Var = ip, variable
+		if err := h.Set(i, entry); err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		if err := h.AddEntry(Entry{IP: ip, Host: host, Var: variable}); err != nil {
+			return nil, err
+		}
+	}
+	return skipped, nil
+}
+
+// RemoveAt removes and returns the entry at index i.
+func (h *Hosts) RemoveAt(i int) Entry {
+	entry := h.entries[i]
+	h.entries = slices.Delete(h.entries, i, i+1)
+	return entry
+}
+
+// Sections returns the names of all sections in the order they appear.
+func (h Hosts) Sections() []string {
+	sections := make([]string, 0)
+	for _, l := range h.lines {
+		if l.section != "" && !slices.Contains(sections, l.section) {
+			sections = append(sections, l.section)
+		}
+	}
+	for _, e := range h.entries {
+		if e.Section != "" && !slices.Contains(sections, e.Section) {
+			sections = append(sections, e.Section)
+		}
+	}
+	return sections
+}
+
+// Clone returns a copy of h that can be modified independently.
+func (h Hosts) Clone() Hosts {
+	return Hosts{
+		entries:  slices.Clone(h.entries),
+		lines:    slices.Clone(h.lines),
+		raw:      h.raw,
+		encoding: h.encoding,
+		eol:      h.eol,
+	}
+}
+
 func (h Hosts) String() string {
 	builder := strings.Builder{}
-	for _, entry := range h.entries {
-		_, _ = builder.WriteString(entry.String() + "\n")
+	for _, line := range h.render() {
+		_, _ = builder.WriteString(line + "\n")
 	}
 	return builder.String()
 }
 
+// render lays out the entries together with the preserved lines of
+// the parsed file. Entries that were not parsed from the file are
+// placed at the end of their section, or at the end of the file.
+func (h Hosts) render() []string {
+	items := slices.Clone(h.lines)
+	added := map[string][]string{}
+	addedOrder := make([]string, 0)
+	for _, e := range h.entries {
+		if e.line == 0 {
+			if _, ok := added[e.Section]; !ok {
+				addedOrder = append(addedOrder, e.Section)
+			}
+			added[e.Section] = append(added[e.Section], e.String())
+			continue
+		}
+		text := e.String()
+		if raw, ok := h.raw[e.line]; ok && raw.entry == text {
+			text = raw.text
+		}
+		items = append(items, textLine{line: e.line, text: text, section: e.Section})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].line < items[j].line
+	})
+
+	insertions := map[int][]string{}
+	tail := make([]string, 0)
+	for _, section := range addedOrder {
+		if section == "" {
+			continue
+		}
+
+		at := -1
+		for i, item := range items {
+			if item.section != section {
+				continue
+			}
+			at = i + 1
+			if item.sectionEnd {
+				at = i
+				break
+			}
+		}
+		if at < 0 {
+			tail = append(tail, sectionBeginPrefix+section)
+			tail = append(tail, added[section]...)
+			tail = append(tail, sectionEndPrefix+section)
+			continue
+		}
+		insertions[at] = append(insertions[at], added[section]...)
+	}
+
+	lines := make([]string, 0, len(items)+len(h.entries))
+	for i, item := range items {
+		lines = append(lines, insertions[i]...)
+		lines = append(lines, item.text)
+	}
+	lines = append(lines, insertions[len(items)]...)
+	lines = append(lines, added[""]...)
+	return append(lines, tail...)
+}
+
+// WriteTo writes the hosts in their encoding and with their line ending.
 func (h Hosts) WriteTo(w io.Writer) (n int64, err error) {
-	_n, err := w.Write([]byte(h.String()))
+	b, err := encode(h.String(), h.Encoding(), h.EOL())
+	if err != nil {
+		return 0, fmt.Errorf("encode: %w", err)
+	}
+	_n, err := w.Write(b)
 	return int64(_n), err
 }
 
 type filterOptions struct {
-	ips       []net.IP
+	ips       []netip.Addr
 	hosts     []string
 	comments  []string
+	sections  []string
+	tags      map[string]string
 	noComment bool
 	matchAll  bool
+	// disabled includes disabled entries, which are skipped otherwise.
+	disabled bool
+	// unprotected excludes protected entries, even with matchAll.
+	unprotected bool
 }
 
 func newFilterOptions(filters ...FilterOption) filterOptions {
@@ -52,13 +243,19 @@ func newFilterOptions(filters ...FilterOption) filterOptions {
 }
 
 func (fo filterOptions) Match(e Entry) bool {
+	if e.Disabled && !fo.disabled {
+		return false
+	}
+	if fo.unprotected && e.Protected() {
+		return false
+	}
 	if fo.matchAll {
 		return true
 	}
 
 	var ipMatch bool
 	for _, ip := range fo.ips {
-		if ip.Equal(e.IP) {
+		if ip.Unmap() == e.IP.Unmap() {
 			ipMatch = true
 		}
 	}
@@ -68,21 +265,40 @@ func (fo filterOptions) Match(e Entry) bool {
 
 	var hostMatch bool
 	for _, host := range fo.hosts {
-		if host == e.Host {
-			hostMatch = true
+		for _, name := range e.Names() {
+			if canonicalName(host) == canonicalName(name) {
+				hostMatch = true
+			}
 		}
 	}
 	if !hostMatch && len(fo.hosts) > 0 {
 		return false
 	}
 
+	var sectionMatch bool
+	for _, section := range fo.sections {
+		if section == e.Section {
+			sectionMatch = true
+		}
+	}
+	if !sectionMatch && len(fo.sections) > 0 {
+		return false
+	}
+
+	for key, value := range fo.tags {
+		if v, ok := e.Tag(key); !ok || v != value {
+			return false
+		}
+	}
+
 	if fo.noComment && strings.TrimSpace(e.Comment) == "" {
 		return true
 	}
 
 	var commentMatch bool
 	for _, comment := range fo.comments {
-		if comment == e.Comment {
+		text, _ := splitMeta(e.Comment)
+		if comment == e.Comment || comment == text {
 			commentMatch = true
 		}
 	}
@@ -101,7 +317,9 @@ func WithAll() FilterOption {
 	}
 }
 
-// Filter entries matching any one of the passed host names.
+// Filter entries with any one of the passed host names, as their host
+// or an alias. Names are compared case insensitively and without a
+// trailing dot.
 func WithHosts(hosts ...string) FilterOption {
 	return func(opts *filterOptions) {
 		if opts.hosts == nil {
@@ -112,10 +330,10 @@ func WithHosts(hosts ...string) FilterOption {
 }
 
 // Filter entries matching any one of the passed IPs.
-func WithIPs(ips ...net.IP) FilterOption {
+func WithIPs(ips ...netip.Addr) FilterOption {
 	return func(opts *filterOptions) {
 		if opts.ips == nil {
-			opts.ips = make([]net.IP, 0, len(ips))
+			opts.ips = make([]netip.Addr, 0, len(ips))
 		}
 		opts.ips = append(opts.ips, ips...)
 	}
@@ -131,6 +349,34 @@ func WithComments(comments ...string) FilterOption {
 	}
 }
 
+// Filter entries in any one of the passed sections.
+func WithSections(sections ...string) FilterOption {
+	return func(opts *filterOptions) {
+		if opts.sections == nil {
+			opts.sections = make([]string, 0, len(sections))
+		}
+		opts.sections = append(opts.sections, sections...)
+	}
+}
+
+// Filter entries having all of the passed tags.
+func WithTags(tags map[string]string) FilterOption {
+	return func(opts *filterOptions) {
+		if opts.tags == nil {
+			opts.tags = make(map[string]string, len(tags))
+		}
+		maps.Copy(opts.tags, tags)
+	}
+}
+
+// Filter disabled entries as well. Without it disabled entries, such
+// as the commented out examples of a stock hosts file, never match.
+func WithDisabled() FilterOption {
+	return func(opts *filterOptions) {
+		opts.disabled = true
+	}
+}
+
 // Filter entries without comments.
 func WithNoComment() FilterOption {
 	return func(opts *filterOptions) {
@@ -138,11 +384,23 @@ func WithNoComment() FilterOption {
 	}
 }
 
+// Find returns the indexes of the entries matching the filters.
+func (h Hosts) Find(filters ...FilterOption) []int {
+	filterOpts := newFilterOptions(filters...)
+	indexes := make([]int, 0)
+	for i, e := range h.entries {
+		if filterOpts.Match(e) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
 func (h *Hosts) Remove(duplicateOnly bool, filters ...FilterOption) []Entry {
 	filterOpts := newFilterOptions(filters...)
 	keptEntries := make([]Entry, 0)
 	removedEntries := make([]Entry, 0)
-	duplicatesCheck := map[string]struct{}{}
+	duplicatesCheck := map[Key]struct{}{}
 	for _, e := range h.entries {
 		hasMatch := filterOpts.Match(e)
 		if !hasMatch {
@@ -155,9 +413,9 @@ func (h *Hosts) Remove(duplicateOnly bool, filters ...FilterOption) []Entry {
 			continue
 		}
 
-		eStr := e.String()
-		if _, ok := duplicatesCheck[eStr]; !ok {
-			duplicatesCheck[eStr] = struct{}{}
+		key := e.Key()
+		if _, ok := duplicatesCheck[key]; !ok {
+			duplicatesCheck[key] = struct{}{}
 			keptEntries = append(keptEntries, e)
 		} else {
 			removedEntries = append(removedEntries, e)
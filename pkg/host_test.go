@@ -1,11 +1,12 @@
 package pkg
 
 import (
-	"net"
+	"net/netip"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFilterOptions(t *testing.T) {
@@ -17,15 +18,15 @@ func TestFilterOptions(t *testing.T) {
 	}{
 		{
 			name:  "happy path: IP",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost"},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"},
 			filter: newFilterOptions(
-				WithIPs(net.IPv4(127, 0, 0, 1)),
+				WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1})),
 			),
 			shouldMatch: true,
 		},
 		{
 			name:  "happy path: Host",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost"},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"},
 			filter: newFilterOptions(
 				WithHosts("localhost"),
 			),
@@ -33,7 +34,7 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:  "happy path: Comment",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "Izu"},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "Izu"},
 			filter: newFilterOptions(
 				WithComments("Izu"),
 			),
@@ -41,7 +42,7 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:  "happy path: No comment",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: ""},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: ""},
 			filter: newFilterOptions(
 				WithNoComment(),
 			),
@@ -49,7 +50,7 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:  "",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: ""},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: ""},
 			filter: newFilterOptions(
 				WithNoComment(),
 				WithHosts(),
@@ -60,15 +61,15 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:  "edge case: nil IP in entry",
-			entry: Entry{IP: nil, Host: "localhost", Comment: "test"},
+			entry: Entry{IP: netip.Addr{}, Host: "localhost", Comment: "test"},
 			filter: newFilterOptions(
-				WithIPs(net.IPv4(127, 0, 0, 1)),
+				WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1})),
 			),
 			shouldMatch: false,
 		},
 		{
 			name:  "edge case: empty host string in filter",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: ""},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: ""},
 			filter: newFilterOptions(
 				WithHosts(""),
 			),
@@ -76,31 +77,47 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:  "edge case: IP in entry is IPv6, filter expects IPv4",
-			entry: Entry{IP: net.ParseIP("::1"), Host: "localhost"},
+			entry: Entry{IP: netip.MustParseAddr("::1"), Host: "localhost"},
 			filter: newFilterOptions(
-				WithIPs(net.IPv4(127, 0, 0, 1)),
+				WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1})),
 			),
 			shouldMatch: false,
 		},
 		{
 			name:  "edge case: IP in entry matches IPv6 ::1",
-			entry: Entry{IP: net.ParseIP("::1"), Host: "localhost"},
+			entry: Entry{IP: netip.MustParseAddr("::1"), Host: "localhost"},
 			filter: newFilterOptions(
-				WithIPs(net.ParseIP("::1")),
+				WithIPs(netip.MustParseAddr("::1")),
 			),
 			shouldMatch: true,
 		},
+		{
+			name:  "edge case: IPv4-mapped IPv6 in entry matches IPv4",
+			entry: Entry{IP: netip.MustParseAddr("::ffff:127.0.0.1"), Host: "localhost"},
+			filter: newFilterOptions(
+				WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1})),
+			),
+			shouldMatch: true,
+		},
+		{
+			name:  "edge case: zoned IPv6 only matches the same zone",
+			entry: Entry{IP: netip.MustParseAddr("fe80::1%eth0"), Host: "router"},
+			filter: newFilterOptions(
+				WithIPs(netip.MustParseAddr("fe80::1%eth1")),
+			),
+			shouldMatch: false,
+		},
 		{
 			name:  "edge case: filter has multiple IPs, one matches",
-			entry: Entry{IP: net.IPv4(10, 0, 0, 1), Host: "example.com"},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "example.com"},
 			filter: newFilterOptions(
-				WithIPs(net.IPv4(127, 0, 0, 1), net.IPv4(10, 0, 0, 1)),
+				WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1}), netip.AddrFrom4([4]byte{10, 0, 0, 1})),
 			),
 			shouldMatch: true,
 		},
 		{
 			name:  "edge case: comment is whitespace only",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "  "},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "  "},
 			filter: newFilterOptions(
 				WithNoComment(),
 			),
@@ -108,29 +125,29 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:        "edge case: empty filters (match all)",
-			entry:       Entry{IP: net.IPv4(8, 8, 8, 8), Host: "dns.google", Comment: "Google DNS"},
+			entry:       Entry{IP: netip.AddrFrom4([4]byte{8, 8, 8, 8}), Host: "dns.google", Comment: "Google DNS"},
 			filter:      newFilterOptions(),
 			shouldMatch: true,
 		},
 		{
 			name:  "extreme case: invalid IP (empty slice)",
-			entry: Entry{IP: net.IP{}, Host: "localhost", Comment: "invalid"},
+			entry: Entry{IP: netip.Addr{}, Host: "localhost", Comment: "invalid"},
 			filter: newFilterOptions(
-				WithIPs(net.IPv4(127, 0, 0, 1)),
+				WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1})),
 			),
 			shouldMatch: false,
 		},
 		{
 			name:  "extreme case: invalid IP string parsing",
-			entry: Entry{IP: net.ParseIP("999.999.999.999"), Host: "localhost"},
+			entry: Entry{IP: netip.Addr{}, Host: "localhost"},
 			filter: newFilterOptions(
-				WithIPs(net.IPv4(127, 0, 0, 1)),
+				WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1})),
 			),
 			shouldMatch: false,
 		},
 		{
 			name:  "extreme case: long hostname",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: strings.Repeat("a", 255)},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: strings.Repeat("a", 255)},
 			filter: newFilterOptions(
 				WithHosts(strings.Repeat("a", 255)),
 			),
@@ -138,7 +155,7 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:  "extreme case: overly long comment (1MB)",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: strings.Repeat("x", 1024*1024)},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: strings.Repeat("x", 1024*1024)},
 			filter: newFilterOptions(
 				WithComments(strings.Repeat("x", 1024*1024)),
 			),
@@ -146,7 +163,7 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:  "extreme case: comment contains newline and special characters",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "Line1\nLine2\t\u2603"},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "Line1\nLine2\t\u2603"},
 			filter: newFilterOptions(
 				WithComments("Line1\nLine2\t\u2603"),
 			),
@@ -154,7 +171,7 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:  "extreme case: filter has no values and expects no comment",
-			entry: Entry{IP: net.IPv4(1, 2, 3, 4), Host: "example", Comment: ""},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{1, 2, 3, 4}), Host: "example", Comment: ""},
 			filter: newFilterOptions(
 				WithNoComment(),
 				WithIPs(),
@@ -165,17 +182,34 @@ func TestFilterOptions(t *testing.T) {
 		},
 		{
 			name:  "extreme case: all fields empty",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "Izu"},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "Izu"},
 			filter: newFilterOptions(
-				WithIPs(nil),
+				WithIPs(netip.Addr{}),
 				WithHosts(""),
 				WithComments(""),
 			),
 			shouldMatch: false,
 		},
+		{
+			name:        "host matches an alias",
+			entry:       Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "api.dev", Aliases: []string{"api", "www.api.dev"}},
+			filter:      newFilterOptions(WithHosts("API")),
+			shouldMatch: true,
+		},
+		{
+			name:   "disabled entry is skipped",
+			entry:  Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Disabled: true},
+			filter: newFilterOptions(WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1}))),
+		},
+		{
+			name:        "disabled entry matches with WithDisabled",
+			entry:       Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Disabled: true},
+			filter:      newFilterOptions(WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1})), WithDisabled()),
+			shouldMatch: true,
+		},
 		{
 			name:  "extreme case: mismatching Unicode comment (NFC vs NFD)",
-			entry: Entry{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "é"},
+			entry: Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "é"},
 			filter: newFilterOptions(
 				WithComments("e\u0301"), // decomposed é
 			),
@@ -190,6 +224,32 @@ func TestFilterOptions(t *testing.T) {
 	}
 }
 
+func TestRemoveLeavesCommentedLines(t *testing.T) {
+	input := `#      102.54.94.97     rhino.acme.com          # source server
+#       38.25.63.10     x.acme.com              # x client host
+# 127.0.0.1 localhost
+127.0.0.1 localhost
+127.0.0.1 localhost
+10.0.0.1 api.dev
+`
+	hosts, err := ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+
+	removed := hosts.Remove(false, WithIPs(netip.AddrFrom4([4]byte{102, 54, 94, 97})))
+	assert.Empty(t, removed)
+	removed = hosts.Remove(true, WithAll())
+	require.Len(t, removed, 1)
+	assert.False(t, removed[0].Disabled)
+	removed = hosts.Remove(false, WithIPs(netip.AddrFrom4([4]byte{127, 0, 0, 1})))
+	require.Len(t, removed, 1)
+
+	assert.Equal(t, `#      102.54.94.97     rhino.acme.com          # source server
+#       38.25.63.10     x.acme.com              # x client host
+# 127.0.0.1 localhost
+10.0.0.1 api.dev
+`, hosts.String())
+}
+
 func TestRemoveDuplicates(t *testing.T) {
 	tests := []struct {
 		input    []Entry
@@ -199,118 +259,177 @@ func TestRemoveDuplicates(t *testing.T) {
 		{
 			name: "happy path: no duplicates",
 			input: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost"},
-				{IP: net.IPv4(8, 8, 8, 8), Host: "dns.google"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"},
+				{IP: netip.AddrFrom4([4]byte{8, 8, 8, 8}), Host: "dns.google"},
 			},
 			expected: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost"},
-				{IP: net.IPv4(8, 8, 8, 8), Host: "dns.google"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"},
+				{IP: netip.AddrFrom4([4]byte{8, 8, 8, 8}), Host: "dns.google"},
 			},
 		},
 		{
 			name: "basic duplicate: exact match",
 			input: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost"},
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"},
 			},
 			expected: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"},
 			},
 		},
 		{
 			name: "duplicate with different comments",
 			input: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "A"},
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "B"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "A"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "B"},
 			},
 			expected: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "A"},
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "B"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "A"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "B"},
 			},
 		},
 		{
 			name: "edge case: nil IP treated as duplicate",
 			input: []Entry{
-				{IP: nil, Host: "localhost"},
-				{IP: nil, Host: "localhost"},
+				{IP: netip.Addr{}, Host: "localhost"},
+				{IP: netip.Addr{}, Host: "localhost"},
 			},
 			expected: []Entry{
-				{IP: nil, Host: "localhost"},
+				{IP: netip.Addr{}, Host: "localhost"},
 			},
 		},
 		{
 			name: "edge case: empty host strings",
 			input: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: ""},
-				{IP: net.IPv4(127, 0, 0, 1), Host: ""},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: ""},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: ""},
 			},
 			expected: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: ""},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: ""},
 			},
 		},
 		{
 			name: "edge case: IPv6 and IPv4 are different",
 			input: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost"},
-				{IP: net.ParseIP("::1"), Host: "localhost"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"},
+				{IP: netip.MustParseAddr("::1"), Host: "localhost"},
 			},
 			expected: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost"},
-				{IP: net.ParseIP("::1"), Host: "localhost"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost"},
+				{IP: netip.MustParseAddr("::1"), Host: "localhost"},
 			},
 		},
 		{
 			name: "duplicate with whitespace-only comment",
 			input: []Entry{
-				{IP: net.IPv4(10, 0, 0, 1), Host: "host", Comment: " "},
-				{IP: net.IPv4(10, 0, 0, 1), Host: "host", Comment: " "},
+				{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "host", Comment: " "},
+				{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "host", Comment: " "},
 			},
 			expected: []Entry{
-				{IP: net.IPv4(10, 0, 0, 1), Host: "host", Comment: " "},
+				{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "host", Comment: " "},
 			},
 		},
 		{
 			name: "extreme: very long host and comment, duplicate",
 			input: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: strings.Repeat("a", 255), Comment: strings.Repeat("x", 1024)},
-				{IP: net.IPv4(127, 0, 0, 1), Host: strings.Repeat("a", 255), Comment: strings.Repeat("x", 1024)},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: strings.Repeat("a", 255), Comment: strings.Repeat("x", 1024)},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: strings.Repeat("a", 255), Comment: strings.Repeat("x", 1024)},
 			},
 			expected: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: strings.Repeat("a", 255), Comment: strings.Repeat("x", 1024)},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: strings.Repeat("a", 255), Comment: strings.Repeat("x", 1024)},
 			},
 		},
 		{
 			name: "unique: differing in comment newline",
 			input: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "Line1"},
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "Line1\n"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "Line1"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "Line1\n"},
 			},
 			expected: []Entry{
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "Line1"},
-				{IP: net.IPv4(127, 0, 0, 1), Host: "localhost", Comment: "Line1\n"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "Line1"},
+				{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "localhost", Comment: "Line1\n"},
 			},
 		},
 		{
 			name: "multiple entries, mix of duplicates and unique",
 			input: []Entry{
-				{IP: net.IPv4(1, 2, 3, 4), Host: "a"},
-				{IP: net.IPv4(1, 2, 3, 4), Host: "a"},
-				{IP: net.IPv4(5, 6, 7, 8), Host: "b"},
-				{IP: net.IPv4(5, 6, 7, 8), Host: "b", Comment: "note"},
+				{IP: netip.AddrFrom4([4]byte{1, 2, 3, 4}), Host: "a"},
+				{IP: netip.AddrFrom4([4]byte{1, 2, 3, 4}), Host: "a"},
+				{IP: netip.AddrFrom4([4]byte{5, 6, 7, 8}), Host: "b"},
+				{IP: netip.AddrFrom4([4]byte{5, 6, 7, 8}), Host: "b", Comment: "note"},
 			},
 			expected: []Entry{
-				{IP: net.IPv4(1, 2, 3, 4), Host: "a"},
-				{IP: net.IPv4(5, 6, 7, 8), Host: "b"},
-				{IP: net.IPv4(5, 6, 7, 8), Host: "b", Comment: "note"},
+				{IP: netip.AddrFrom4([4]byte{1, 2, 3, 4}), Host: "a"},
+				{IP: netip.AddrFrom4([4]byte{5, 6, 7, 8}), Host: "b"},
+				{IP: netip.AddrFrom4([4]byte{5, 6, 7, 8}), Host: "b", Comment: "note"},
 			},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			hosts := NewHosts(test.input)
+			hosts := Hosts{entries: test.input}
 			hosts.Remove(true, WithAll())
 			assert.ElementsMatch(t, test.expected, hosts.Entries())
 		})
 	}
 }
+
+func TestPointHost(t *testing.T) {
+	input := "# @docker = 172.17.0.1\n127.0.0.1 localhost\n10.0.0.1 api.dev\nfd00::1 api.dev\n# 10.0.0.5 api.dev\n"
+	tests := []struct {
+		name     string
+		host     string
+		ip       string
+		variable string
+		force    bool
+		expected string
+		skipped  int
+	}{
+		{
+			name:     "updates enabled entries",
+			host:     "API.dev",
+			ip:       "10.0.0.2",
+			expected: "# @docker = 172.17.0.1\n127.0.0.1 localhost\n10.0.0.2 api.dev\n10.0.0.2 api.dev\n# 10.0.0.5 api.dev\n",
+		},
+		{
+			name:     "binds to a variable",
+			host:     "api.dev",
+			ip:       "172.17.0.1",
+			variable: "docker",
+			expected: "# @docker = 172.17.0.1\n127.0.0.1 localhost\n172.17.0.1 api.dev # @docker\n172.17.0.1 api.dev # @docker\n# 10.0.0.5 api.dev\n",
+		},
+		{
+			name:     "adds a missing host",
+			host:     "db.dev",
+			ip:       "10.0.0.3",
+			expected: input + "10.0.0.3 db.dev\n",
+		},
+		{
+			name:     "skips protected entries",
+			host:     "localhost",
+			ip:       "10.0.0.3",
+			expected: input,
+			skipped:  1,
+		},
+		{
+			name:     "changes protected entries with force",
+			host:     "localhost",
+			ip:       "10.0.0.3",
+			force:    true,
+			expected: "# @docker = 172.17.0.1\n10.0.0.3 localhost\n10.0.0.1 api.dev\nfd00::1 api.dev\n# 10.0.0.5 api.dev\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hosts, err := ParseEntries(strings.NewReader(input))
+			require.NoError(t, err)
+
+			skipped, err := hosts.PointHost(test.host, netip.MustParseAddr(test.ip), test.variable, test.force)
+			require.NoError(t, err)
+			assert.Len(t, skipped, test.skipped)
+			assert.Equal(t, test.expected, hosts.String())
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var ErrInvalidHost = errors.New("invalid host name")
+
+const (
+	maxHostLength  = 253
+	maxLabelLength = 63
+)
+
+// ValidateHost checks that name is a valid host name as defined by
+// RFC 952 and RFC 1123, after converting internationalized names to
+// their ASCII form as defined by RFC 5891. A trailing dot is allowed.
+// Wildcards such as "*.example.com" are rejected, as resolvers match
+// the names of a hosts file literally.
+func ValidateHost(name string) error {
+	host := strings.TrimSuffix(name, ".")
+	if host == "" {
+		return invalidHostErr(name, "empty name")
+	}
+	if strings.Contains(host, "*") {
+		return invalidHostErr(name, "wildcards are not supported in hosts files")
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return invalidHostErr(name, err.Error())
+	}
+	if len(ascii) > maxHostLength {
+		return invalidHostErr(name, fmt.Sprintf("longer than %d characters", maxHostLength))
+	}
+
+	for _, label := range strings.Split(ascii, ".") {
+		switch {
+		case label == "":
+			return invalidHostErr(name, "empty label")
+		case len(label) > maxLabelLength:
+			return invalidHostErr(name, fmt.Sprintf("label %q is longer than %d characters", label, maxLabelLength))
+		case label[0] == '-' || label[len(label)-1] == '-':
+			return invalidHostErr(name, fmt.Sprintf("label %q starts or ends with a hyphen", label))
+		}
+		for _, c := range label {
+			if !isLetterDigitHyphen(c) {
+				return invalidHostErr(name, fmt.Sprintf("label %q contains %q", label, c))
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks that the entry has an address and valid host names.
+func (e Entry) Validate() error {
+	if !e.IP.IsValid() {
+		return fmt.Errorf("%w: missing address", ErrInvalidIP)
+	}
+	for _, name := range e.Names() {
+		if err := ValidateHost(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateChanged validates entry as a change of old, skipping the names
+// old already had.
+func (e Entry) validateChanged(old Entry) error {
+	if !e.IP.IsValid() {
+		return fmt.Errorf("%w: missing address", ErrInvalidIP)
+	}
+	oldNames := old.Names()
+	for _, name := range e.Names() {
+		if slices.Contains(oldNames, name) {
+			continue
+		}
+		if err := ValidateHost(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HostToASCII returns the ASCII (punycode) form of a host name.
+func HostToASCII(name string) (string, error) {
+	return mapHost(name, idna.Lookup.ToASCII)
+}
+
+// HostToUnicode returns the Unicode form of a host name.
+func HostToUnicode(name string) (string, error) {
+	return mapHost(name, idna.Display.ToUnicode)
+}
+
+func mapHost(name string, fn func(string) (string, error)) (string, error) {
+	host, dot := strings.CutSuffix(name, ".")
+	mapped, err := fn(host)
+	if err != nil {
+		return name, invalidHostErr(name, err.Error())
+	}
+	if dot {
+		mapped += "."
+	}
+	return mapped, nil
+}
+
+// canonicalName returns the form of name used to compare host names,
+// which ignores case, a trailing dot and whether internationalized
+// names are written in Unicode or punycode.
+func canonicalName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if ascii, err := HostToASCII(name); err == nil {
+		return ascii
+	}
+	return name
+}
+
+func isLetterDigitHyphen(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-'
+}
+
+func invalidHostErr(name, reason string) error {
+	return fmt.Errorf("%w %q: %s", ErrInvalidHost, name, reason)
+}
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateHost(t *testing.T) {
+	valid := []string{
+		"localhost",
+		"api.dev",
+		"meep-mino",
+		"1password.local",
+		"example.com.",
+		"bücher.example",
+		"xn--bcher-kva.example",
+		strings.Repeat("a", 63) + ".com",
+	}
+	for _, name := range valid {
+		assert.NoError(t, ValidateHost(name), name)
+	}
+
+	invalid := []string{
+		"",
+		".",
+		"bad_name",
+		"-leading.dev",
+		"trailing-.dev",
+		"double..dot",
+		"a.*.wildcard",
+		"*.pc.local",
+		"k#",
+		strings.Repeat("a", 64) + ".com",
+		strings.Repeat("a.", 127) + "com",
+	}
+	for _, name := range invalid {
+		assert.ErrorIs(t, ValidateHost(name), ErrInvalidHost, name)
+	}
+}
+
+func TestInvalidHost(t *testing.T) {
+	// Files with names that are not valid host names still parse, so
+	// they can be changed, but the names are reported by Lint.
+	input := "127.0.0.1 api.dev bad_alias\n# 127.0.0.1 my_host\n127.0.0.1 k#\n"
+	hosts, err := ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, hosts.Entries(), 3)
+	assert.Equal(t, input, hosts.String())
+	assert.Equal(t, []string{
+		`line 1: invalid host name "bad_alias": idna: disallowed rune U+005F`,
+		`line 2: invalid host name "my_host": idna: disallowed rune U+005F`,
+		`line 3: invalid host name "k#": idna: disallowed rune U+0023`,
+	}, hosts.Lint())
+
+	// Entries with invalid names can be changed, but not given new
+	// invalid names.
+	entry := hosts.Entries()[0]
+	entry.Disabled = true
+	require.NoError(t, hosts.Set(0, entry))
+	entry.Aliases = append(entry.Aliases, "other_alias")
+	assert.ErrorIs(t, hosts.Set(0, entry), ErrInvalidHost)
+
+	var empty Hosts
+	assert.ErrorIs(t, empty.AddEntry(Entry{IP: netip.MustParseAddr("127.0.0.1"), Host: "bad_name"}), ErrInvalidHost)
+	assert.ErrorIs(t, empty.AddEntry(Entry{Host: "api.dev"}), ErrInvalidIP)
+	assert.Empty(t, empty.Entries())
+
+	_, err = NewHosts([]Entry{{IP: netip.MustParseAddr("127.0.0.1"), Host: "bad_name"}})
+	assert.ErrorIs(t, err, ErrInvalidHost)
+}
+
+func TestHostDisplay(t *testing.T) {
+	ascii, err := HostToASCII("www.Bücher.example.")
+	require.NoError(t, err)
+	assert.Equal(t, "www.xn--bcher-kva.example.", ascii)
+
+	unicode, err := HostToUnicode("xn--bcher-kva.example")
+	require.NoError(t, err)
+	assert.Equal(t, "bücher.example", unicode)
+}
+
+func TestFilterHostsCanonical(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader("127.0.0.1 API.dev\n127.0.0.1 bücher.example\n127.0.0.1 other.dev\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{0}, hosts.Find(WithHosts("api.dev.")))
+	assert.Equal(t, []int{1}, hosts.Find(WithHosts("xn--bcher-kva.example")))
+}
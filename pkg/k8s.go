@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"errors"
+	"io"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+
+// K8sObject is the subset of an Ingress, a Service, or a List of them,
+// needed to find the host names they serve.
+type K8sObject struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name        string            `yaml:"name"`
+		Namespace   string            `yaml:"namespace"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Type  string `yaml:"type"`
+		Rules []struct {
+			Host string `yaml:"host"`
+		} `yaml:"rules"`
+	} `yaml:"spec"`
+	Status struct {
+		LoadBalancer struct {
+			Ingress []struct {
+				Hostname string `yaml:"hostname"`
+			} `yaml:"ingress"`
+		} `yaml:"loadBalancer"`
+	} `yaml:"status"`
+	Items []K8sObject `yaml:"items"`
+}
+
+// DecodeK8sObjects decodes the objects of one or more YAML or JSON
+// documents read from r.
+func DecodeK8sObjects(r io.Reader) ([]K8sObject, error) {
+	objects := make([]K8sObject, 0)
+	dec := yaml.NewDecoder(r)
+	for {
+		var obj K8sObject
+		err := dec.Decode(&obj)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// K8sEntries returns an entry pointing at ip for every host name served
+// by an Ingress or LoadBalancer Service, tagged with the object it came
+// from as source. A name served by more than one object gets a single
+// entry. Names that are not valid host names are skipped and returned
+// as errors.
+func K8sEntries(objects []K8sObject, ip netip.Addr) ([]Entry, []error) {
+	entries := make([]Entry, 0)
+	var errs []error
+	seen := map[string]bool{}
+	var visit func(objects []K8sObject)
+	visit = func(objects []K8sObject) {
+		for _, obj := range objects {
+			if len(obj.Items) > 0 {
+				visit(obj.Items)
+				continue
+			}
+
+			hosts := make([]string, 0)
+			switch obj.Kind {
+			case "Ingress":
+				for _, rule := range obj.Spec.Rules {
+					hosts = append(hosts, rule.Host)
+				}
+			case "Service":
+				if obj.Spec.Type != "LoadBalancer" {
+					continue
+				}
+				for _, host := range strings.Split(obj.Metadata.Annotations[externalDNSHostnameAnnotation], ",") {
+					hosts = append(hosts, strings.TrimSpace(host))
+				}
+				for _, ingress := range obj.Status.LoadBalancer.Ingress {
+					hosts = append(hosts, ingress.Hostname)
+				}
+			default:
+				continue
+			}
+
+			source := strings.ToLower(obj.Kind) + "/" + obj.Metadata.Name
+			if obj.Metadata.Namespace != "" {
+				source = strings.ToLower(obj.Kind) + "/" + obj.Metadata.Namespace + "/" + obj.Metadata.Name
+			}
+			// Hosts are marked as seen while filtering, so a host
+			// listed twice by the same object is only kept once.
+			hosts = slices.DeleteFunc(hosts, func(host string) bool {
+				if host == "" || seen[host] {
+					return true
+				}
+				seen[host] = true
+				return false
+			})
+			for _, host := range hosts {
+				entry := Entry{IP: ip, Host: host}
+				err := ValidateHost(host)
+				if err == nil {
+					err = entry.SetTag("source", source)
+				}
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+	visit(objects)
+	return entries, errs
+}
@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestK8sEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		errs     int
+	}{
+		{
+			name: "ingress rules",
+			input: `kind: Ingress
+metadata: {name: web, namespace: shop}
+spec:
+  rules: [{host: shop.dev}, {host: api.shop.dev}]
+`,
+			expected: []string{
+				"10.0.0.1 shop.dev # [source=ingress/shop/web]",
+				"10.0.0.1 api.shop.dev # [source=ingress/shop/web]",
+			},
+		},
+		{
+			name: "host repeated in one ingress",
+			input: `kind: Ingress
+metadata: {name: web}
+spec:
+  rules: [{host: shop.dev}, {host: shop.dev}, {host: ""}]
+`,
+			expected: []string{"10.0.0.1 shop.dev # [source=ingress/web]"},
+		},
+		{
+			name: "host repeated across objects",
+			input: `kind: Ingress
+metadata: {name: web}
+spec:
+  rules: [{host: shop.dev}]
+---
+kind: Ingress
+metadata: {name: other}
+spec:
+  rules: [{host: shop.dev}]
+`,
+			expected: []string{"10.0.0.1 shop.dev # [source=ingress/web]"},
+		},
+		{
+			name: "load balancer services in a list",
+			input: `{"kind": "List", "items": [
+  {"kind": "Service", "metadata": {"name": "lb", "annotations": {"external-dns.alpha.kubernetes.io/hostname": "a.dev, b.dev"}},
+   "spec": {"type": "LoadBalancer"}, "status": {"loadBalancer": {"ingress": [{"hostname": "a.dev"}]}}},
+  {"kind": "Service", "metadata": {"name": "internal"}, "spec": {"type": "ClusterIP"}}
+]}`,
+			expected: []string{
+				"10.0.0.1 a.dev # [source=service/lb]",
+				"10.0.0.1 b.dev # [source=service/lb]",
+			},
+		},
+		{
+			name: "invalid host skipped",
+			input: `kind: Ingress
+metadata: {name: web}
+spec:
+  rules: [{host: bad_name.dev}, {host: shop.dev}]
+`,
+			expected: []string{"10.0.0.1 shop.dev # [source=ingress/web]"},
+			errs:     1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objects, err := DecodeK8sObjects(strings.NewReader(test.input))
+			require.NoError(t, err)
+
+			entries, errs := K8sEntries(objects, netip.MustParseAddr("10.0.0.1"))
+			actual := make([]string, 0, len(entries))
+			for _, e := range entries {
+				actual = append(actual, e.String())
+			}
+			assert.Equal(t, test.expected, actual)
+			assert.Len(t, errs, test.errs)
+			for _, err := range errs {
+				assert.ErrorIs(t, err, ErrInvalidHost)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package pkg
+
+import "fmt"
+
+// Lint returns warnings about entries that are likely mistakes, such as
+// names that are not valid host names, duplicate entries and names
+// pointing at more than one address of the same family.
+func (h Hosts) Lint() []string {
+	warnings := make([]string, 0)
+	seen := map[Key]Entry{}
+	type family struct {
+		name string
+		is4  bool
+	}
+	addrs := map[family]Entry{}
+	for _, e := range h.entries {
+		for _, name := range e.Names() {
+			if err := ValidateHost(name); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: %s", where(e), err))
+			}
+		}
+		if first, ok := seen[e.Key()]; ok {
+			warnings = append(warnings, fmt.Sprintf("%s: %s is a duplicate of %s", where(e), e, where(first)))
+			continue
+		}
+		seen[e.Key()] = e
+		if e.Disabled {
+			continue
+		}
+
+		for _, name := range e.Names() {
+			f := family{name: canonicalName(name), is4: e.IP.Unmap().Is4()}
+			first, ok := addrs[f]
+			if !ok {
+				addrs[f] = e
+				continue
+			}
+			if first.IP.Unmap() != e.IP.Unmap() {
+				warnings = append(warnings, fmt.Sprintf("%s: %s points at %s but %s already points it at %s", where(e), name, e.IP, where(first), first.IP))
+			}
+		}
+	}
+	return warnings
+}
+
+func where(e Entry) string {
+	if e.line == 0 {
+		return "new entry"
+	}
+	return fmt.Sprintf("line %d", e.line)
+}
@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader(`127.0.0.1 localhost
+::1 localhost
+10.0.0.1 api.dev
+10.0.0.1 api.dev
+10.0.0.2 API.dev.
+# 10.0.0.3 api.dev
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"line 4: 10.0.0.1 api.dev is a duplicate of line 3",
+		"line 5: API.dev. points at 10.0.0.2 but line 3 already points it at 10.0.0.1",
+	}, hosts.Lint())
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Locks older than this are assumed to be left behind by a process
+// that died while holding them.
+const staleLockAge = 30 * time.Second
+
+const lockRetryInterval = 50 * time.Millisecond
+
+var ErrLocked = errors.New("hosts file is locked by another process")
+
+// Lock takes an exclusive lock on the hosts file at path, waiting until
+// the lock is available or ctx is done. The lock is advisory and only
+// respected by other users of Lock. The returned function releases it.
+func Lock(ctx context.Context, path string) (func() error, error) {
+	lockPath := path + ".lock"
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, _ = file.WriteString(strconv.Itoa(os.Getpid()))
+			file.Close()
+			return func() error { return os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("create lock file: %w", err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", ErrLocked, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
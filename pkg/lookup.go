@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Names returns the host name and aliases of the entry.
+func (e Entry) Names() []string {
+	return append([]string{e.Host}, e.Aliases...)
+}
+
+// LookupHost returns the addresses of the enabled entries naming host,
+// in the order they appear in the file. Names are compared case
+// insensitively and without a trailing dot.
+func (h Hosts) LookupHost(host string) []netip.Addr {
+	host = canonicalName(host)
+	ips := make([]netip.Addr, 0)
+	for _, e := range h.entries {
+		if e.Disabled {
+			continue
+		}
+		for _, name := range e.Names() {
+			if canonicalName(name) == host {
+				ips = append(ips, e.IP)
+				break
+			}
+		}
+	}
+	return ips
+}
+
+// LookupAddr returns the names of the enabled entries for ip, in the
+// order they appear in the file. Zones are ignored, so fe80::1 matches
+// an entry for fe80::1%eth0.
+func (h Hosts) LookupAddr(ip netip.Addr) []string {
+	ip = ip.Unmap().WithZone("")
+	names := make([]string, 0)
+	for _, e := range h.entries {
+		if !e.Disabled && e.IP.Unmap().WithZone("") == ip {
+			names = append(names, e.Names()...)
+		}
+	}
+	return names
+}
+
+// ReverseAddr returns the IP address of an in-addr.arpa or ip6.arpa name,
+// such as the name of a PTR query, or the zero Addr if name is not one.
+func ReverseAddr(name string) netip.Addr {
+	name = strings.ToLower(name)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	if rest, ok := strings.CutSuffix(name, ".in-addr.arpa."); ok {
+		labels := strings.Split(rest, ".")
+		if len(labels) != 4 {
+			return netip.Addr{}
+		}
+		var ip [4]byte
+		for i, label := range labels {
+			b, err := strconv.ParseUint(label, 10, 8)
+			if err != nil {
+				return netip.Addr{}
+			}
+			ip[3-i] = byte(b)
+		}
+		return netip.AddrFrom4(ip)
+	}
+
+	if rest, ok := strings.CutSuffix(name, ".ip6.arpa."); ok {
+		nibbles := strings.Split(rest, ".")
+		if len(nibbles) != 32 {
+			return netip.Addr{}
+		}
+		var ip [16]byte
+		for i, nibble := range nibbles {
+			n, err := strconv.ParseUint(nibble, 16, 4)
+			if err != nil {
+				return netip.Addr{}
+			}
+			pos := 31 - i
+			ip[pos/2] |= byte(n) << (4 * (1 - pos%2))
+		}
+		return netip.AddrFrom16(ip)
+	}
+
+	return netip.Addr{}
+}
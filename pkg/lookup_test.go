@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader(`
+10.0.0.1 api.dev api
+# 10.0.0.9 api.dev
+::1 API.dev
+10.0.0.2 web.dev api
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("::1")},
+		hosts.LookupHost("api.dev."),
+	)
+	assert.Equal(t,
+		[]netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.0.0.2")},
+		hosts.LookupHost("API"),
+	)
+	assert.Empty(t, hosts.LookupHost("missing.dev"))
+
+	assert.Equal(t, []string{"api.dev", "api"}, hosts.LookupAddr(netip.MustParseAddr("10.0.0.1")))
+	assert.Empty(t, hosts.LookupAddr(netip.MustParseAddr("10.0.0.9")))
+}
+
+func TestLookupAddrZone(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader("fe80::1%eth0 router.lan\n::ffff:10.0.0.1 api.dev\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"router.lan"}, hosts.LookupAddr(netip.MustParseAddr("fe80::1")))
+	assert.Equal(t, []string{"router.lan"}, hosts.LookupAddr(netip.MustParseAddr("fe80::1%eth1")))
+	assert.Equal(t, []string{"api.dev"}, hosts.LookupAddr(netip.MustParseAddr("10.0.0.1")))
+}
+
+func TestReverseAddr(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "1.0.0.10.in-addr.arpa.", expected: "10.0.0.1"},
+		{name: "1.0.0.10.IN-ADDR.ARPA", expected: "10.0.0.1"},
+		{name: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.e.f.ip6.arpa.", expected: "fe80::1"},
+		{name: "b.a.9.8.7.6.5.0.4.0.0.0.3.0.0.0.2.0.0.0.1.0.0.0.0.0.0.0.1.2.3.4.ip6.arpa.", expected: "4321:0:1:2:3:4:567:89ab"},
+		{name: "0.0.10.in-addr.arpa."},
+		{name: "256.0.0.10.in-addr.arpa."},
+		{name: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.e.ip6.arpa."},
+		{name: "g.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.e.f.ip6.arpa."},
+		{name: "api.dev."},
+	}
+
+	for _, test := range tests {
+		ip := ReverseAddr(test.name)
+		if test.expected == "" {
+			assert.False(t, ip.IsValid(), test.name)
+			continue
+		}
+		assert.Equal(t, netip.MustParseAddr(test.expected), ip, test.name)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
+	"net/netip"
+	"strings"
+	"unicode"
 )
 
 var (
@@ -15,51 +17,197 @@ var (
 )
 
 type Entry struct {
-	IP      net.IP
-	Host    string
-	Comment string
+	// IP is the entry's address. IPv6 addresses may have a zone, such
+	// as fe80::1%eth0.
+	IP   netip.Addr `json:"ip"`
+	Host string     `json:"host"`
+	// Aliases are additional host names on the same line.
+	Aliases []string `json:"aliases,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+	// Var is the name of the variable the entry's IP is bound to, if any.
+	Var string `json:"var,omitempty"`
+	// Disabled entries are kept in the file but commented out.
+	Disabled bool `json:"disabled,omitempty"`
+	// Section is the name of the section the entry belongs to, if any.
+	Section string `json:"section,omitempty"`
+
+	// line is the entry's line number in the parsed file, or 0 if
+	// the entry was not parsed from a file.
+	line int
+}
+
+// Key is the comparable form of an entry. Entries with equal keys are
+// duplicates of each other, and unlike Entry it can be used as a map key.
+type Key struct {
+	IP       netip.Addr
+	Names    string
+	Comment  string
+	Disabled bool
+}
+
+// Key returns the key of the entry. IPv4-mapped IPv6 addresses have the
+// same key as their IPv4 address.
+func (e Entry) Key() Key {
+	return Key{
+		IP:       e.IP.Unmap(),
+		Names:    strings.Join(e.Names(), " "),
+		Comment:  joinVariable(e.Comment, e.Var),
+		Disabled: e.Disabled,
+	}
 }
 
 func (e Entry) String() string {
 	str := fmt.Sprintf("%s %s", e.IP.String(), e.Host)
-	if e.Comment != "" {
-		str += " " + e.Comment
+	for _, alias := range e.Aliases {
+		str += " " + alias
+	}
+	if comment := joinVariable(e.Comment, e.Var); comment != "" {
+		str += " " + comment
+	}
+	if e.Disabled {
+		str = "# " + str
 	}
 	return str
 }
 
+// ParseError describes a line of a hosts file that could not be parsed.
+type ParseError struct {
+	// Line and Column are the 1-based position of the error.
+	Line   int
+	Column int
+	// Raw is the text of the line.
+	Raw string
+	// Err is ErrInvalidIP or ErrInvalidEntry, wrapped with details.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseEntries parses a hosts file. It fails with a *ParseError at the
+// first line that can not be parsed.
 func ParseEntries(r io.Reader) (Hosts, error) {
-	entries := make([]Entry, 0)
-	buf := bufio.NewReader(r)
+	hosts, errs, err := parseEntries(r, false)
+	if err != nil {
+		return Hosts{}, err
+	}
+	if len(errs) > 0 {
+		return Hosts{}, errs[0]
+	}
+	return hosts, nil
+}
+
+// ParseEntriesLenient parses a hosts file, keeping the lines that can
+// not be parsed as they are and returning an error for each of them.
+// The returned error is only non-nil if r could not be read.
+func ParseEntriesLenient(r io.Reader) (Hosts, []*ParseError, error) {
+	return parseEntries(r, true)
+}
+
+func parseEntries(r io.Reader, lenient bool) (Hosts, []*ParseError, error) {
+	var parseErrs []*ParseError
+	hosts := Hosts{entries: make([]Entry, 0), raw: map[int]textLine{}}
+	decoded, encoding, err := decodeReader(r)
+	if err != nil {
+		return Hosts{}, nil, fmt.Errorf("detect encoding: %w", err)
+	}
+	hosts.encoding = encoding
+	buf := bufio.NewReader(decoded)
+	var section string
 	ln := -1
 	for {
 		ln += 1
 		b, err := buf.ReadBytes('\n')
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			} else {
-				return Hosts{}, lineParseErr(ln, fmt.Errorf("read bytes: %s", err))
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Hosts{}, nil, lineParseErr(ln, fmt.Errorf("read bytes: %s", err))
+		}
+		if len(b) == 0 {
+			break
+		}
+		eof := err != nil
+		if hosts.eol == "" && bytes.HasSuffix(b, []byte{'\n'}) {
+			hosts.eol = LF
+			if bytes.HasSuffix(b, []byte("\r\n")) {
+				hosts.eol = CRLF
 			}
 		}
 
-		b = bytes.TrimSpace(b)
-		if len(b) == 0 {
-			continue
+		text := textLine{
+			line:    ln + 1,
+			text:    strings.TrimRight(string(b), "\r\n"),
+			section: section,
 		}
 
-		if bytes.HasPrefix(b, []byte{'#'}) {
-			continue
+		b = bytes.TrimSpace(b)
+		switch {
+		case len(b) == 0:
+			hosts.lines = append(hosts.lines, text)
+		case bytes.HasPrefix(b, []byte{'#'}):
+			if name, ok := sectionStart(b); ok {
+				section = name
+				text.section = name
+				hosts.lines = append(hosts.lines, text)
+				break
+			}
+			if v, ok := parseVariable(b); ok {
+				text.variable = v
+				hosts.lines = append(hosts.lines, text)
+				break
+			}
+			if section != "" && isSectionEnd(b, section) {
+				text.sectionEnd = true
+				section = ""
+				hosts.lines = append(hosts.lines, text)
+				break
+			}
+
+			// Commented out lines that still parse as entries
+			// are treated as disabled entries.
+			entry, err := parseEntry(bytes.TrimSpace(b[1:]))
+			if err != nil {
+				hosts.lines = append(hosts.lines, text)
+				break
+			}
+			entry.Disabled = true
+			hosts.addParsed(entry, text)
+		default:
+			entry, err := parseEntry(b)
+			if err != nil {
+				parseErrs = append(parseErrs, &ParseError{
+					Line:   ln + 1,
+					Column: len(text.text) - len(strings.TrimLeftFunc(text.text, unicode.IsSpace)) + 1,
+					Raw:    text.text,
+					Err:    err,
+				})
+				if !lenient {
+					return Hosts{}, parseErrs, nil
+				}
+				hosts.lines = append(hosts.lines, text)
+				break
+			}
+			hosts.addParsed(entry, text)
 		}
 
-		entry, err := parseEntry(b)
-		if err != nil {
-			return Hosts{}, lineParseErr(ln, err)
+		if eof {
+			break
 		}
-		entries = append(entries, entry)
 	}
 
-	return Hosts{entries: entries}, nil
+	hosts.bindVariables()
+	return hosts, parseErrs, nil
+}
+
+func (h *Hosts) addParsed(entry Entry, text textLine) {
+	entry.Section = text.section
+	entry.line = text.line
+	text.entry = entry.String()
+	h.raw[text.line] = text
+	h.entries = append(h.entries, entry)
 }
 
 // Expect b to be trimmed of all leading and trailing whitespace as defined by Unicode
@@ -74,29 +222,66 @@ func parseEntry(b []byte) (Entry, error) {
 		return Entry{}, fmt.Errorf("empty host")
 	}
 
+	var aliases []string
+	rest := parts[2:]
+	for len(rest) > 0 && rest[0][0] != '#' {
+		aliases = append(aliases, string(rest[0]))
+		rest = rest[1:]
+	}
+
 	var commentStr string
-	if len(parts) > 2 {
-		commentParts := parts[2:]
-		comment := bytes.Join(commentParts, []byte{' '})
-		if comment[0] != '#' {
-			invalidEntryErr(b)
-		}
-		commentStr = string(comment)
+	if len(rest) > 0 {
+		commentStr = string(bytes.Join(rest, []byte{' '}))
 	}
 
-	var m net.IP
-	err := m.UnmarshalText(ip)
+	addr, err := netip.ParseAddr(string(ip))
 	if err != nil {
 		return Entry{}, invalidIPErr(ip)
 	}
 
 	return Entry{
-		IP:      m,
+		IP:      addr,
 		Host:    string(host),
+		Aliases: aliases,
 		Comment: commentStr,
 	}, nil
 }
 
+// Section markers written by whosts.
+const (
+	sectionBeginPrefix = "# BEGIN "
+	sectionEndPrefix   = "# END "
+)
+
+// Section markers as written by Docker Desktop.
+const (
+	dockerSection      = "Docker Desktop"
+	dockerSectionStart = "# Added by Docker Desktop"
+	dockerSectionEnd   = "# End of section"
+)
+
+// Expect b to be a trimmed comment line.
+func sectionStart(b []byte) (string, bool) {
+	if string(b) == dockerSectionStart {
+		return dockerSection, true
+	}
+	name, ok := bytes.CutPrefix(b, []byte(sectionBeginPrefix))
+	if !ok {
+		return "", false
+	}
+	name = bytes.TrimSpace(name)
+	return string(name), len(name) > 0
+}
+
+// Expect b to be a trimmed comment line.
+func isSectionEnd(b []byte, section string) bool {
+	if string(b) == dockerSectionEnd {
+		return true
+	}
+	name, ok := bytes.CutPrefix(b, []byte(sectionEndPrefix))
+	return ok && string(bytes.TrimSpace(name)) == section
+}
+
 func invalidIPErr(d []byte) error {
 	return fmt.Errorf("%w:%s", ErrInvalidIP, d)
 }
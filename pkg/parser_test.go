@@ -1,7 +1,7 @@
 package pkg
 
 import (
-	"net"
+	"net/netip"
 	"strings"
 	"testing"
 
@@ -48,7 +48,7 @@ func TestParseEntry(t *testing.T) {
 		{
 			"109.94.209.70   fitgirl-repack.net      # Fake FitGirl site",
 			Entry{
-				IP:      net.IPv4(109, 94, 209, 70),
+				IP:      netip.AddrFrom4([4]byte{109, 94, 209, 70}),
 				Host:    "fitgirl-repack.net",
 				Comment: "# Fake FitGirl site",
 			},
@@ -56,7 +56,7 @@ func TestParseEntry(t *testing.T) {
 		{
 			"127.0.0.1       kubernetes.docker.internal      #",
 			Entry{
-				IP:      net.IPv4(127, 0, 0, 1),
+				IP:      netip.AddrFrom4([4]byte{127, 0, 0, 1}),
 				Host:    "kubernetes.docker.internal",
 				Comment: "#",
 			},
@@ -64,38 +64,47 @@ func TestParseEntry(t *testing.T) {
 		{
 			"127.0.0.1 k#",
 			Entry{
-				IP:   net.IPv4(127, 0, 0, 1),
+				IP:   netip.AddrFrom4([4]byte{127, 0, 0, 1}),
 				Host: "k#",
 			},
 		},
 		{
 			"127.0.0.1 #k#",
 			Entry{
-				IP:   net.IPv4(127, 0, 0, 1),
+				IP:   netip.AddrFrom4([4]byte{127, 0, 0, 1}),
 				Host: "#k#",
 			},
 		},
 		{
 			"127.0.0.1 mino",
 			Entry{
-				IP:   net.IPv4(127, 0, 0, 1),
+				IP:   netip.AddrFrom4([4]byte{127, 0, 0, 1}),
 				Host: "mino",
 			},
 		},
 		{
 			"188.245.227.222 meep-mino",
 			Entry{
-				IP:   net.IPv4(188, 245, 227, 222),
+				IP:   netip.AddrFrom4([4]byte{188, 245, 227, 222}),
 				Host: "meep-mino",
 			},
 		},
 		{
 			"127.0.0.1 localhost.com",
 			Entry{
-				IP:   net.IPv4(127, 0, 0, 1),
+				IP:   netip.AddrFrom4([4]byte{127, 0, 0, 1}),
 				Host: "localhost.com",
 			},
 		},
+		{
+			"10.0.0.1 api.dev api www.api.dev # API",
+			Entry{
+				IP:      netip.AddrFrom4([4]byte{10, 0, 0, 1}),
+				Host:    "api.dev",
+				Aliases: []string{"api", "www.api.dev"},
+				Comment: "# API",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -104,6 +113,114 @@ func TestParseEntry(t *testing.T) {
 			require.NoError(t, err)
 			assert.Equal(t, entry.Host, tt.expected.Host)
 			assert.Equal(t, entry.IP, tt.expected.IP)
+			assert.Equal(t, entry.Aliases, tt.expected.Aliases)
+		})
+	}
+}
+
+func TestParseFileKeepsLayout(t *testing.T) {
+	input := "# header\n\n127.0.0.1 localhost\n#\t::1 localhost\n# BEGIN dev\n10.0.0.1 api.dev # API\n# END dev\n127.0.0.1 last"
+
+	hosts, err := ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, hosts.entries, 4)
+
+	assert.True(t, hosts.entries[1].Disabled)
+	assert.Equal(t, "::1", hosts.entries[1].IP.String())
+	assert.Equal(t, "dev", hosts.entries[2].Section)
+	assert.Equal(t, "", hosts.entries[3].Section)
+	assert.Equal(t, []string{"dev"}, hosts.Sections())
+
+	assert.Equal(t, input+"\n", hosts.String())
+
+	entry := hosts.entries[1]
+	entry.Disabled = false
+	require.NoError(t, hosts.Set(1, entry))
+	assert.Equal(t,
+		"# header\n\n127.0.0.1 localhost\n::1 localhost\n# BEGIN dev\n10.0.0.1 api.dev # API\n# END dev\n127.0.0.1 last\n",
+		hosts.String(),
+	)
+}
+
+func TestParseFileDockerSection(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader(
+		"# Added by Docker Desktop\n192.168.18.175 host.docker.internal\n# End of section\n127.0.0.1 other\n",
+	))
+	require.NoError(t, err)
+	require.Len(t, hosts.entries, 2)
+	assert.Equal(t, "Docker Desktop", hosts.entries[0].Section)
+	assert.Equal(t, "", hosts.entries[1].Section)
+}
+
+func TestHostsAddEntryToSection(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader("# BEGIN dev\n10.0.0.1 api.dev\n# END dev\n127.0.0.1 localhost\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, hosts.AddEntry(Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 2}), Host: "web.dev", Section: "dev"}))
+	require.NoError(t, hosts.AddEntry(Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 3}), Host: "db.test", Section: "test"}))
+	require.NoError(t, hosts.AddEntry(Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "new"}))
+
+	assert.Equal(t,
+		"# BEGIN dev\n10.0.0.1 api.dev\n10.0.0.2 web.dev\n# END dev\n127.0.0.1 localhost\n127.0.0.1 new\n# BEGIN test\n10.0.0.3 db.test\n# END test\n",
+		hosts.String(),
+	)
+}
+
+func TestParseTestdata(t *testing.T) {
+	for _, name := range []string{"../testdata/hosts.txt", "../testdata/hosts-with-header.txt"} {
+		t.Run(name, func(t *testing.T) {
+			hosts, err := ReadFile(name)
+			require.NoError(t, err)
+			assert.Len(t, hosts.Entries(), 40)
+
+			reparsed, err := ParseEntries(strings.NewReader(hosts.String()))
+			require.NoError(t, err)
+			assert.Empty(t, Diff(hosts, reparsed))
 		})
 	}
 }
+
+func TestParseEntriesLenient(t *testing.T) {
+	input := "127.0.0.1 localhost\n  127.0.0.x broken\nonlyhost\n# comment\n10.0.0.1 api.dev\n"
+
+	_, err := ParseEntries(strings.NewReader(input))
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 2, parseErr.Line)
+	assert.ErrorIs(t, err, ErrInvalidIP)
+
+	hosts, errs, err := ParseEntriesLenient(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+
+	assert.Equal(t, 2, errs[0].Line)
+	assert.Equal(t, 3, errs[0].Column)
+	assert.Equal(t, "  127.0.0.x broken", errs[0].Raw)
+	assert.ErrorIs(t, errs[0], ErrInvalidIP)
+
+	assert.Equal(t, 3, errs[1].Line)
+	assert.Equal(t, 1, errs[1].Column)
+	assert.ErrorIs(t, errs[1], ErrInvalidEntry)
+
+	require.Len(t, hosts.Entries(), 2)
+	assert.Equal(t, "api.dev", hosts.Entries()[1].Host)
+	// Unparseable lines are kept as they are.
+	assert.Equal(t, input, hosts.String())
+}
+
+func TestParseZonedAndMapped(t *testing.T) {
+	input := "fe80::1%eth0 router.local\n::ffff:10.0.0.1 mapped.local\n10.0.0.1 mapped.local\n"
+	hosts, err := ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+	entries := hosts.Entries()
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "eth0", entries[0].IP.Zone())
+	assert.Equal(t, "fe80::1%eth0 router.local", entries[0].String())
+	assert.Equal(t, "::ffff:10.0.0.1 mapped.local", entries[1].String())
+	assert.Equal(t, entries[1].Key(), entries[2].Key())
+
+	removed := hosts.Remove(true, WithAll())
+	require.Len(t, removed, 1)
+	assert.Equal(t, "fe80::1%eth0 router.local\n::ffff:10.0.0.1 mapped.local\n", hosts.String())
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"net/netip"
+	"strings"
+)
+
+const protectedKey = "protected"
+
+// Protected reports whether the entry is protected from being removed
+// or changed by accident. Entries are protected by a tag, e.g.
+// "# gateway [protected=true]", and entries for localhost, ::1 and the
+// ip6-* names are always protected.
+func (e Entry) Protected() bool {
+	if value, ok := e.Tag(protectedKey); ok && value == "true" {
+		return true
+	}
+	return e.builtinProtected()
+}
+
+// SetProtected tags the entry as protected, or removes the tag. Entries
+// that are always protected stay protected.
+func (e *Entry) SetProtected(protected bool) {
+	if protected {
+		_ = e.SetTag(protectedKey, "true")
+		return
+	}
+	e.DeleteTag(protectedKey)
+}
+
+func (e Entry) builtinProtected() bool {
+	if e.IP.Unmap() == netip.IPv6Loopback() {
+		return true
+	}
+	for _, name := range e.Names() {
+		name = canonicalName(name)
+		if name == "localhost" || strings.HasPrefix(name, "ip6-") {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveUnprotected removes the entries matching filters, or only their
+// duplicates, like Remove, except for protected entries. It returns the
+// removed entries and the protected ones that would otherwise have been
+// removed.
+func (h *Hosts) RemoveUnprotected(duplicateOnly bool, filters ...FilterOption) ([]Entry, []Entry) {
+	var skipped []Entry
+	all := h.Clone()
+	for _, e := range all.Remove(duplicateOnly, filters...) {
+		if e.Protected() {
+			skipped = append(skipped, e)
+		}
+	}
+	return h.Remove(duplicateOnly, append(filters, WithUnprotected())...), skipped
+}
+
+// Filter entries that are not protected.
+func WithUnprotected() FilterOption {
+	return func(opts *filterOptions) {
+		opts.unprotected = true
+	}
+}
@@ -0,0 +1,39 @@
+package pkg
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtected(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader(
+		"127.0.0.1 localhost\n::1 ip6-localhost ip6-loopback\nfe00::0 ip6-localnet\n10.0.0.1 gateway # router [protected=true]\n10.0.0.2 api.dev\n",
+	))
+	require.NoError(t, err)
+
+	entries := hosts.Entries()
+	for _, e := range entries[:4] {
+		assert.True(t, e.Protected(), e.String())
+	}
+	assert.False(t, entries[4].Protected())
+
+	removed := hosts.Remove(false, WithAll(), WithUnprotected())
+	require.Len(t, removed, 1)
+	assert.Equal(t, "api.dev", removed[0].Host)
+
+	api := Entry{IP: netip.MustParseAddr("10.0.0.2"), Host: "api.dev"}
+	api.SetProtected(true)
+	assert.Equal(t, "10.0.0.2 api.dev # [protected=true]", api.String())
+	api.SetProtected(false)
+	assert.False(t, api.Protected())
+	assert.Equal(t, "10.0.0.2 api.dev", api.String())
+
+	// Entries that are always protected stay protected.
+	localhost := entries[0]
+	localhost.SetProtected(false)
+	assert.True(t, localhost.Protected())
+}
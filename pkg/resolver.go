@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// Resolver looks up names in a Hosts value before falling back to a
+// regular resolver, without touching the system hosts file. Its lookup
+// methods mirror those of net.Resolver and DialContext can be used as
+// the dialer of an http.Transport.
+type Resolver struct {
+	Hosts Hosts
+	// Fallback resolves names that are not in Hosts. If nil,
+	// net.DefaultResolver is used.
+	Fallback *net.Resolver
+	// Dialer is used by DialContext to make connections. If nil, a zero
+	// net.Dialer is used.
+	Dialer *net.Dialer
+}
+
+func NewResolver(h Hosts) *Resolver {
+	return &Resolver{Hosts: h}
+}
+
+func (r *Resolver) fallback() *net.Resolver {
+	if r.Fallback != nil {
+		return r.Fallback
+	}
+	return net.DefaultResolver
+}
+
+// LookupHost looks up the given host, returning its addresses.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if ips := r.Hosts.LookupHost(host); len(ips) > 0 {
+		addrs := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			addrs = append(addrs, ip.String())
+		}
+		return addrs, nil
+	}
+	return r.fallback().LookupHost(ctx, host)
+}
+
+// LookupIPAddr looks up host, returning its IP addresses.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if ips := r.Hosts.LookupHost(host); len(ips) > 0 {
+		addrs := make([]net.IPAddr, 0, len(ips))
+		for _, ip := range ips {
+			addrs = append(addrs, net.IPAddr{IP: ip.AsSlice(), Zone: ip.Zone()})
+		}
+		return addrs, nil
+	}
+	return r.fallback().LookupIPAddr(ctx, host)
+}
+
+// LookupIP looks up host for the given network, which must be "ip",
+// "ip4" or "ip6".
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if ips := r.Hosts.LookupHost(host); len(ips) > 0 {
+		matching := filterIPs(ips, network)
+		if len(matching) == 0 {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		result := make([]net.IP, 0, len(matching))
+		for _, ip := range matching {
+			result = append(result, ip.AsSlice())
+		}
+		return result, nil
+	}
+	return r.fallback().LookupIP(ctx, network, host)
+}
+
+// LookupAddr performs a reverse lookup for the given address, returning
+// the names mapping to it.
+func (r *Resolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	if ip, err := netip.ParseAddr(addr); err == nil {
+		if names := r.Hosts.LookupAddr(ip); len(names) > 0 {
+			return names, nil
+		}
+	}
+	return r.fallback().LookupAddr(ctx, addr)
+}
+
+// DialContext connects to address on the named network, resolving the
+// host of address with r.
+func (r *Resolver) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := r.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := r.Hosts.LookupHost(host)
+	if len(ips) == 0 {
+		d := *dialer
+		d.Resolver = r.fallback()
+		return d.DialContext(ctx, network, address)
+	}
+
+	var ipNetwork string
+	switch network {
+	case "tcp4", "udp4":
+		ipNetwork = "ip4"
+	case "tcp6", "udp6":
+		ipNetwork = "ip6"
+	default:
+		ipNetwork = "ip"
+	}
+
+	var errs []error
+	for _, ip := range filterIPs(ips, ipNetwork) {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: fmt.Errorf("no %s address for %s", ipNetwork, host)}
+	}
+	return nil, errors.Join(errs...)
+}
+
+func filterIPs(ips []netip.Addr, network string) []netip.Addr {
+	matching := make([]netip.Addr, 0, len(ips))
+	for _, ip := range ips {
+		is4 := ip.Unmap().Is4()
+		if network == "ip" || (network == "ip4" && is4) || (network == "ip6" && !is4) {
+			matching = append(matching, ip)
+		}
+	}
+	return matching
+}
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolverDialContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Host)
+	}))
+	defer srv.Close()
+
+	hosts, err := ParseEntries(strings.NewReader("127.0.0.1 api.example.com\n"))
+	require.NoError(t, err)
+
+	resolver := NewResolver(hosts)
+	client := &http.Client{Transport: &http.Transport{DialContext: resolver.DialContext}}
+
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+	resp, err := client.Get("http://api.example.com:" + port)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "api.example.com:"+port, string(body))
+}
+
+func TestResolverLookup(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader("127.0.0.1 api.example.com api\n::1 api.example.com\n"))
+	require.NoError(t, err)
+
+	errFallback := errors.New("fallback")
+	resolver := NewResolver(hosts)
+	resolver.Fallback = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errFallback
+		},
+	}
+	ctx := context.Background()
+
+	addrs, err := resolver.LookupHost(ctx, "api.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1", "::1"}, addrs)
+
+	ips, err := resolver.LookupIP(ctx, "ip6", "api.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []net.IP{net.ParseIP("::1")}, ips)
+
+	names, err := resolver.LookupAddr(ctx, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.example.com", "api"}, names)
+
+	_, err = resolver.LookupHost(ctx, "unknown.invalid")
+	var dnsErr *net.DNSError
+	assert.ErrorAs(t, err, &dnsErr)
+}
@@ -0,0 +1,51 @@
+package pkg
+
+// SectionEntries returns the entries in the named section.
+func (h Hosts) SectionEntries(name string) []Entry {
+	entries := make([]Entry, 0)
+	for _, e := range h.entries {
+		if e.Section == name {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ReplaceSection makes the named section contain exactly the passed
+// entries and returns the changes this made. Entries outside of the
+// section are left untouched, as are entries of the section that are
+// kept. The section is created at the end of the file if it does not
+// exist yet.
+func (h *Hosts) ReplaceSection(name string, entries []Entry) []Change {
+	desired := make([]Entry, len(entries))
+	for i, e := range entries {
+		e.Section = name
+		e.line = 0
+		desired[i] = e
+	}
+	changes := Diff(Hosts{entries: h.SectionEntries(name)}, Hosts{entries: desired})
+
+	used := make([]bool, len(desired))
+	kept := make([]Entry, 0, len(h.entries))
+	for _, e := range h.entries {
+		if e.Section != name {
+			kept = append(kept, e)
+			continue
+		}
+		for j, d := range desired {
+			if !used[j] && d.String() == e.String() {
+				used[j] = true
+				kept = append(kept, e)
+				break
+			}
+		}
+	}
+	for j, d := range desired {
+		if !used[j] {
+			kept = append(kept, d)
+		}
+	}
+
+	h.entries = kept
+	return changes
+}
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceSection(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader(`127.0.0.1 localhost
+# BEGIN dev
+10.0.0.1	api.dev
+10.0.0.2	web.dev
+10.0.0.3	old.dev
+# END dev
+10.0.0.1 outside.dev
+`))
+	require.NoError(t, err)
+
+	api := Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "api.dev"}
+	web := Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 9}), Host: "web.dev"}
+	db := Entry{IP: netip.AddrFrom4([4]byte{10, 0, 0, 4}), Host: "db.dev"}
+
+	changes := hosts.ReplaceSection("dev", []Entry{api, web, db})
+	require.Len(t, changes, 3)
+	assert.Equal(t, Changed, changes[0].Kind)
+	assert.Equal(t, "web.dev", changes[0].New.Host)
+	assert.Equal(t, Added, changes[1].Kind)
+	assert.Equal(t, "db.dev", changes[1].New.Host)
+	assert.Equal(t, Removed, changes[2].Kind)
+	assert.Equal(t, "old.dev", changes[2].Old.Host)
+
+	assert.Equal(t, `127.0.0.1 localhost
+# BEGIN dev
+10.0.0.1	api.dev
+10.0.0.9 web.dev
+10.0.0.4 db.dev
+# END dev
+10.0.0.1 outside.dev
+`, hosts.String())
+
+	assert.Empty(t, hosts.ReplaceSection("dev", []Entry{api, web, db}))
+}
+
+func TestReplaceSectionCreatesSection(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader("127.0.0.1 localhost\n"))
+	require.NoError(t, err)
+
+	hosts.ReplaceSection("dev", []Entry{{IP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), Host: "api.dev"}})
+	assert.Equal(t, "127.0.0.1 localhost\n# BEGIN dev\n10.0.0.1 api.dev\n# END dev\n", hosts.String())
+	assert.Len(t, hosts.SectionEntries("dev"), 1)
+}
@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var tagKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Tag returns the value of the entry's tag with the given key. Tags are
+// stored in the metadata block at the end of the entry's comment, e.g.
+// "# note [owner=platform ticket=OPS-12]". Entry has no Tags field, so
+// the comment is the only copy of them: copies of an entry never share
+// tags, and a change to the comment cannot leave a stale map behind.
+func (e Entry) Tag(key string) (string, bool) {
+	_, meta := splitMeta(e.Comment)
+	for _, kv := range meta {
+		if kv[0] == key {
+			return kv[1], true
+		}
+	}
+	return "", false
+}
+
+// Tags returns the entry's tags.
+func (e Entry) Tags() map[string]string {
+	_, meta := splitMeta(e.Comment)
+	if len(meta) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(meta))
+	for _, kv := range meta {
+		tags[kv[0]] = kv[1]
+	}
+	return tags
+}
+
+// SetTag sets the entry's tag with the given key to value. New tags are
+// added to the end of the metadata block.
+func (e *Entry) SetTag(key, value string) error {
+	if err := validateTag(key, value); err != nil {
+		return err
+	}
+	text, meta := splitMeta(e.Comment)
+	var found bool
+	for i, kv := range meta {
+		if kv[0] == key {
+			meta[i][1] = value
+			found = true
+		}
+	}
+	if !found {
+		meta = append(meta, [2]string{key, value})
+	}
+	e.Comment = joinMeta(text, meta)
+	return nil
+}
+
+// SetTags replaces the entry's tags with tags, written in key order.
+func (e *Entry) SetTags(tags map[string]string) error {
+	keys := make([]string, 0, len(tags))
+	for key, value := range tags {
+		if err := validateTag(key, value); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	text, _ := splitMeta(e.Comment)
+	meta := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		meta = append(meta, [2]string{key, tags[key]})
+	}
+	e.Comment = joinMeta(text, meta)
+	return nil
+}
+
+// DeleteTag removes the entry's tag with the given key.
+func (e *Entry) DeleteTag(key string) {
+	if _, ok := e.Tag(key); !ok {
+		return
+	}
+	text, meta := splitMeta(e.Comment)
+	meta = slices.DeleteFunc(meta, func(kv [2]string) bool {
+		return kv[0] == key
+	})
+	e.Comment = joinMeta(text, meta)
+}
+
+// ParseTag parses a tag of the form "key=value".
+func ParseTag(s string) (string, string, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", "", fmt.Errorf("invalid tag %q, expected key=value", s)
+	}
+	if err := validateTag(key, value); err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func validateTag(key, value string) error {
+	if !tagKeyRegexp.MatchString(key) {
+		return fmt.Errorf("invalid tag key %q", key)
+	}
+	if value == "" || strings.ContainsAny(value, " \t[]=#") {
+		return fmt.Errorf("invalid value %q for tag %q", value, key)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTags(t *testing.T) {
+	input := `# @docker = 192.168.18.175
+127.0.0.1 api.local # note [owner=platform ticket=OPS-12]
+127.0.0.1 web.local # [ticket=OPS-13 owner=web]
+127.0.0.1 db.local # not [tags]
+192.168.18.175 gateway.local # gateway [owner=platform] # @docker
+`
+	hosts, err := ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+	entries := hosts.Entries()
+	require.Len(t, entries, 4)
+
+	assert.Equal(t, map[string]string{"owner": "platform", "ticket": "OPS-12"}, entries[0].Tags())
+	assert.Equal(t, map[string]string{"owner": "web", "ticket": "OPS-13"}, entries[1].Tags())
+	assert.Nil(t, entries[2].Tags())
+	assert.Equal(t, "# gateway [owner=platform]", entries[3].Comment)
+	assert.Equal(t, "docker", entries[3].Var)
+	assert.Equal(t, map[string]string{"owner": "platform"}, entries[3].Tags())
+
+	assert.Equal(t, input, hosts.String())
+
+	assert.Equal(t, []int{0, 3}, hosts.Find(WithTags(map[string]string{"owner": "platform"})))
+	assert.Equal(t, []int{0}, hosts.Find(WithTags(map[string]string{"owner": "platform", "ticket": "OPS-12"})))
+	assert.Empty(t, hosts.Find(WithTags(map[string]string{"owner": "nobody"})))
+}
+
+func TestSetTag(t *testing.T) {
+	entry := Entry{IP: netip.AddrFrom4([4]byte{127, 0, 0, 1}), Host: "api.local", Comment: "# note"}
+	require.NoError(t, entry.SetTag("ticket", "OPS-12"))
+	require.NoError(t, entry.SetTag("owner", "platform"))
+	assert.Equal(t, "127.0.0.1 api.local # note [ticket=OPS-12 owner=platform]", entry.String())
+
+	require.NoError(t, entry.SetTags(map[string]string{"owner": "web", "env": "dev"}))
+	assert.Equal(t, "# note [env=dev owner=web]", entry.Comment)
+
+	assert.Error(t, entry.SetTag("bad key", "x"))
+	assert.Error(t, entry.SetTag("key", "two words"))
+	assert.Error(t, entry.SetTag("key", ""))
+
+	entry.DeleteTag("owner")
+	entry.DeleteTag("env")
+	assert.Nil(t, entry.Tags())
+	assert.Equal(t, "127.0.0.1 api.local # note", entry.String())
+
+	key, value, err := ParseTag("owner=platform")
+	require.NoError(t, err)
+	assert.Equal(t, "owner", key)
+	assert.Equal(t, "platform", value)
+	_, _, err = ParseTag("owner")
+	assert.Error(t, err)
+}
+
+func TestCloneTags(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader("127.0.0.1 api.local # [owner=platform]\n"))
+	require.NoError(t, err)
+
+	clone := hosts.Clone()
+	entry := clone.Entries()[0]
+	require.NoError(t, entry.SetTag("owner", "web"))
+	require.NoError(t, clone.Set(0, entry))
+
+	owner, _ := hosts.Entries()[0].Tag("owner")
+	assert.Equal(t, "platform", owner)
+}
@@ -0,0 +1,174 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+var ErrUndefinedVariable = errors.New("variable is not defined")
+
+var variableNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Variable is a named address defined in the hosts file with a comment
+// of the form "# @name = ip". Entries bound to a variable follow it
+// when it is changed.
+type Variable struct {
+	Name string     `json:"name"`
+	IP   netip.Addr `json:"ip"`
+}
+
+func (v Variable) String() string {
+	return fmt.Sprintf("# @%s = %s", v.Name, v.IP)
+}
+
+// Variables returns the variables defined in the file.
+func (h Hosts) Variables() []Variable {
+	vars := make([]Variable, 0)
+	for _, l := range h.lines {
+		if l.variable.Name != "" {
+			vars = append(vars, l.variable)
+		}
+	}
+	return vars
+}
+
+// Variable returns the address of the named variable.
+func (h Hosts) Variable(name string) (netip.Addr, bool) {
+	for _, v := range h.Variables() {
+		if v.Name == name {
+			return v.IP, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// ResolveAddr returns the address s stands for, which is either an IP
+// address or @name of a variable, along with the name of the variable.
+func (h Hosts) ResolveAddr(s string) (netip.Addr, string, error) {
+	name, ok := strings.CutPrefix(s, "@")
+	if !ok {
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Addr{}, "", invalidIPErr([]byte(s))
+		}
+		return ip, "", nil
+	}
+
+	ip, ok := h.Variable(name)
+	if !ok {
+		return netip.Addr{}, "", fmt.Errorf("@%s: %w", name, ErrUndefinedVariable)
+	}
+	return ip, name, nil
+}
+
+// SetVariable defines the named variable, or changes its address if it
+// already exists, and points every entry bound to it at ip.
+func (h *Hosts) SetVariable(name string, ip netip.Addr) error {
+	if !variableNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	v := Variable{Name: name, IP: ip}
+
+	var found bool
+	for i, l := range h.lines {
+		if l.variable.Name == name {
+			h.lines[i].variable = v
+			h.lines[i].text = v.String()
+			found = true
+		}
+	}
+	if !found {
+		// New definitions go below the comments at the top of the file,
+		// which are often a header describing it.
+		h.lines = append(h.lines, textLine{line: h.headerEnd(), text: v.String(), variable: v})
+	}
+
+	for i, e := range h.entries {
+		if e.Var == name {
+			h.entries[i].IP = ip
+		}
+	}
+	return nil
+}
+
+// headerEnd returns the number of the last line of the comments at the
+// top of the file, or 0 if the file does not start with a comment.
+func (h Hosts) headerEnd() int {
+	end := 0
+	for _, l := range h.lines {
+		if l.line != end+1 || l.section != "" || !strings.HasPrefix(strings.TrimSpace(l.text), "#") {
+			break
+		}
+		end = l.line
+	}
+	return end
+}
+
+// Expect b to be a trimmed comment line.
+func parseVariable(b []byte) (Variable, bool) {
+	rest := bytes.TrimSpace(b[1:])
+	rest, ok := bytes.CutPrefix(rest, []byte{'@'})
+	if !ok {
+		return Variable{}, false
+	}
+	name, value, ok := bytes.Cut(rest, []byte{'='})
+	if !ok {
+		return Variable{}, false
+	}
+
+	name, value = bytes.TrimSpace(name), bytes.TrimSpace(value)
+	if !variableNameRegexp.Match(name) {
+		return Variable{}, false
+	}
+	ip, err := netip.ParseAddr(string(value))
+	if err != nil {
+		return Variable{}, false
+	}
+	return Variable{Name: string(name), IP: ip}, true
+}
+
+// bindVariables binds entries whose comment ends with a reference to a
+// defined variable, such as "# gateway # @docker", to that variable.
+// Comments that merely end with @name, such as "# ask @docker", are
+// left alone.
+func (h *Hosts) bindVariables() {
+	for i, e := range h.entries {
+		comment, name := splitVariable(e.Comment)
+		if name == "" {
+			continue
+		}
+		if _, ok := h.Variable(name); !ok {
+			continue
+		}
+		h.entries[i].Comment = comment
+		h.entries[i].Var = name
+	}
+}
+
+func splitVariable(comment string) (string, string) {
+	i := strings.LastIndex(comment, "# @")
+	if i < 0 {
+		return comment, ""
+	}
+	name := strings.TrimSpace(comment[i+len("# @"):])
+	if !variableNameRegexp.MatchString(name) {
+		return comment, ""
+	}
+	return strings.TrimSpace(comment[:i]), name
+}
+
+// joinVariable is the inverse of splitVariable.
+func joinVariable(comment, name string) string {
+	switch {
+	case name == "":
+		return comment
+	case comment == "":
+		return "# @" + name
+	default:
+		return comment + " # @" + name
+	}
+}
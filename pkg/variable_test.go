@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariables(t *testing.T) {
+	input := `# @docker = 192.168.18.175
+192.168.18.175	host.docker.internal	# @docker
+192.168.18.175 gateway.docker.internal # gateway # @docker
+192.168.18.175 unbound.docker.internal # @unknown
+192.168.18.175 mail.docker.internal # ask @docker
+`
+	hosts, err := ParseEntries(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, input, hosts.String())
+
+	ip, ok := hosts.Variable("docker")
+	require.True(t, ok)
+	assert.Equal(t, "192.168.18.175", ip.String())
+
+	entries := hosts.Entries()
+	assert.Equal(t, "docker", entries[0].Var)
+	assert.Equal(t, "", entries[0].Comment)
+	assert.Equal(t, "docker", entries[1].Var)
+	assert.Equal(t, "# gateway", entries[1].Comment)
+	assert.Equal(t, "", entries[2].Var)
+	assert.Equal(t, "# @unknown", entries[2].Comment)
+	// Only the trailing "# @name" form binds an entry.
+	assert.Equal(t, "", entries[3].Var)
+	assert.Equal(t, "# ask @docker", entries[3].Comment)
+
+	require.NoError(t, hosts.SetVariable("docker", netip.MustParseAddr("10.0.75.1")))
+	assert.Equal(t, `# @docker = 10.0.75.1
+10.0.75.1 host.docker.internal # @docker
+10.0.75.1 gateway.docker.internal # gateway # @docker
+192.168.18.175 unbound.docker.internal # @unknown
+192.168.18.175 mail.docker.internal # ask @docker
+`, hosts.String())
+}
+
+func TestSetVariableDefines(t *testing.T) {
+	hosts, err := ParseEntries(strings.NewReader("127.0.0.1 localhost\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, hosts.SetVariable("gw", netip.MustParseAddr("10.0.0.1")))
+	require.NoError(t, hosts.AddEntry(Entry{IP: netip.MustParseAddr("10.0.0.1"), Host: "gw.local", Var: "gw"}))
+	assert.Equal(t, "# @gw = 10.0.0.1\n127.0.0.1 localhost\n10.0.0.1 gw.local # @gw\n", hosts.String())
+	assert.Equal(t, []Variable{{Name: "gw", IP: netip.MustParseAddr("10.0.0.1")}}, hosts.Variables())
+
+	assert.Error(t, hosts.SetVariable("not valid", netip.MustParseAddr("10.0.0.1")))
+}
+
+func TestSetVariableBelowHeader(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "# Copyright\n# hosts file\n\n127.0.0.1 localhost\n",
+			expected: "# Copyright\n# hosts file\n# @gw = 10.0.0.1\n\n127.0.0.1 localhost\n",
+		},
+		{
+			input:    "# hosts file\n# @docker = 172.17.0.1\n127.0.0.1 localhost\n# comment\n",
+			expected: "# hosts file\n# @docker = 172.17.0.1\n# @gw = 10.0.0.1\n127.0.0.1 localhost\n# comment\n",
+		},
+		{
+			input:    "# 127.0.0.1 disabled.local\n# comment\n",
+			expected: "# @gw = 10.0.0.1\n# 127.0.0.1 disabled.local\n# comment\n",
+		},
+		{
+			input:    "# BEGIN dev\n10.0.0.2 api.dev\n# END dev\n",
+			expected: "# @gw = 10.0.0.1\n# BEGIN dev\n10.0.0.2 api.dev\n# END dev\n",
+		},
+	}
+
+	for _, test := range tests {
+		hosts, err := ParseEntries(strings.NewReader(test.input))
+		require.NoError(t, err)
+
+		require.NoError(t, hosts.SetVariable("gw", netip.MustParseAddr("10.0.0.1")))
+		assert.Equal(t, test.expected, hosts.String(), test.input)
+	}
+}